@@ -0,0 +1,52 @@
+// Command export-view prints the configuration of an existing view as a
+// logdna_view resource, e.g. to adopt the views of an account:
+//
+//	LOGDNA_SERVICE_KEY=... go run ./cmd/export-view -name errors <view id> >> views.tf
+//
+// Credentials are rendered as placeholders which must be replaced before
+// applying, then the view is imported with terraform import.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/logdna/terraform-provider-logdna/logdna"
+)
+
+func main() {
+	url := flag.String("url", "https://api.logdna.com", "URL of the LogDNA API")
+	name := flag.String("name", "", "name of the logdna_view resource (default: view_<view id>)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-url URL] [-name NAME] VIEW_ID\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "The service key is read from LOGDNA_SERVICE_KEY.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	serviceKey := os.Getenv("LOGDNA_SERVICE_KEY")
+	if flag.NArg() != 1 || serviceKey == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	viewID := flag.Arg(0)
+	if *name == "" {
+		*name = "view_" + viewID
+	}
+
+	src, diags := logdna.ExportViewHCL(context.Background(), *url, serviceKey, viewID, *name)
+	for _, d := range diags {
+		level := "Warning"
+		if d.Severity == diag.Error {
+			level = "Error"
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s %s\n", level, d.Summary, d.Detail)
+	}
+	if diags.HasError() {
+		os.Exit(1)
+	}
+	os.Stdout.Write(src)
+}
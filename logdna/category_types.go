@@ -0,0 +1,74 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// categoryTypes are the kinds of Categories, each of which can only be
+// attached to the resources of its kind
+var categoryTypes = []string{"views", "boards", "screens"}
+
+// validateCategoryType fails when one of names is not a Category of wantType
+// but one of another type, which LogDNA would not attach to the resource.
+// Names matching no Category at all are left to the API, and so are the
+// Categories which cannot be listed.
+func validateCategoryType(ctx context.Context, pc *providerConfig, resource string, wantType string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	missing := names
+	for _, categoryType := range append([]string{wantType}, otherCategoryTypes(wantType)...) {
+		req := newRequestConfig(
+			pc,
+			"GET",
+			pc.endpoint("category.list", categoryType),
+			nil,
+		)
+		categories, err := Do[[]categoryResponse](ctx, req)
+		if err != nil {
+			log.Printf("[WARN] Cannot list the %s categories, the categories of %s are not validated: %s", categoryType, resource, err)
+			return nil
+		}
+
+		notFound := []string{}
+		for _, name := range missing {
+			if !hasCategory(categories, name) {
+				notFound = append(notFound, name)
+				continue
+			}
+			if categoryType != wantType {
+				return fmt.Errorf(
+					"category %q is a %s category, a %s only accepts %s categories",
+					name, categoryType, resource, wantType,
+				)
+			}
+		}
+		if missing = notFound; len(missing) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+func otherCategoryTypes(wantType string) []string {
+	others := []string{}
+	for _, categoryType := range categoryTypes {
+		if categoryType != wantType {
+			others = append(others, categoryType)
+		}
+	}
+	return others
+}
+
+// hasCategory matches names case-insensitively, like the API does
+func hasCategory(categories []categoryResponse, name string) bool {
+	for _, category := range categories {
+		if strings.EqualFold(category.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package logdna
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const accountDataSourceID = "account"
+
+// dataSourceAccountRead exports the plan and the feature flags of the account.
+// A service key which is not allowed to read them produces a warning instead
+// of an error: the plan and tier are left null and no feature is reported, so
+// that configurations gating on them fall back to their defaults.
+func dataSourceAccountRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	pc := m.(*providerConfig)
+
+	req := newRequestConfig(pc, "GET", pc.endpoint("account.read"), nil)
+	account, err := Do[accountResponse](ctx, req)
+	if isPermissionErr(err) || (pc.ignoreUnavailableFeatures && isNotFoundErr(err)) {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Cannot read the account information, its plan and features are left empty",
+			Detail:   err.Error(),
+		})
+		account = accountResponse{}
+	} else if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Cannot read the remote account information",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+	log.Printf("[DEBUG] Account plan %q, tier %q, %d features", account.Plan, account.Tier, len(account.Features))
+
+	if account.Plan != "" {
+		appendError(d.Set("plan", account.Plan), &diags)
+	}
+	if account.Tier != "" {
+		appendError(d.Set("tier", account.Tier), &diags)
+	}
+	appendError(d.Set("features", account.Features), &diags)
+
+	d.SetId(accountDataSourceID)
+	return diags
+}
+
+func dataSourceAccount() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceAccountRead,
+		Schema: map[string]*schema.Schema{
+			"plan": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"tier": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"features": {
+				Type:     schema.TypeMap,
+				Elem:     &schema.Schema{Type: schema.TypeBool},
+				Computed: true,
+			},
+		},
+	}
+}
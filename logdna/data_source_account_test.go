@@ -0,0 +1,68 @@
+package logdna
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataAccount_Read(t *testing.T) {
+	assert := assert.New(t)
+
+	read := func(status int, body string, pc providerConfig) (*schema.ResourceData, diag.Diagnostics) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal("/v1/config/account", r.URL.Path, "URL path is correct")
+			w.WriteHeader(status)
+			_, err := w.Write([]byte(body))
+			assert.Nil(err, "No errors")
+		}))
+		defer ts.Close()
+
+		pc.baseURL = ts.URL
+		pc.httpClient = &http.Client{Timeout: 15 * time.Second}
+		d := schema.TestResourceDataRaw(t, dataSourceAccount().Schema, map[string]interface{}{})
+		return d, dataSourceAccountRead(context.Background(), d, &pc)
+	}
+
+	t.Run("Returns the plan and features", func(t *testing.T) {
+		d, diags := read(200, `{"plan": "enterprise", "tier": "pro", "features": {"archiving": true, "streaming": false}}`, providerConfig{})
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal("account", d.Id(), "ID is set")
+		assert.Equal("enterprise", d.Get("plan"))
+		assert.Equal("pro", d.Get("tier"))
+		assert.Equal(map[string]interface{}{"archiving": true, "streaming": false}, d.Get("features"))
+	})
+
+	t.Run("Warns when the service key cannot read the account", func(t *testing.T) {
+		for _, status := range []int{401, 403} {
+			d, diags := read(status, `{"error": "not allowed"}`, providerConfig{})
+			assert.False(diags.HasError(), "No errors for %d", status)
+			assert.Len(diags, 1, "A warning for %d", status)
+			assert.Equal(diag.Warning, diags[0].Severity, "A warning for %d", status)
+			assert.Equal("account", d.Id(), "ID is set for %d", status)
+			plan, ok := d.GetOk("plan")
+			assert.False(ok, "No plan for %d, got %v", status, plan)
+			assert.Empty(d.Get("features"), "No features for %d", status)
+		}
+	})
+
+	t.Run("Fails on quota errors", func(t *testing.T) {
+		_, diags := read(403, `{"error": "Your plan limit was reached, please upgrade"}`, providerConfig{})
+		assert.True(diags.HasError(), "A quota error is not a permission error")
+	})
+
+	t.Run("Honors ignore_unavailable_features on a missing endpoint", func(t *testing.T) {
+		_, diags := read(404, `{"error": "not found"}`, providerConfig{})
+		assert.True(diags.HasError(), "A 404 fails by default")
+
+		d, diags := read(404, `{"error": "not found"}`, providerConfig{ignoreUnavailableFeatures: true})
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal("account", d.Id(), "ID is set")
+	})
+}
@@ -14,16 +14,24 @@ var intSchema = &schema.Schema{
 	Type:     schema.TypeInt,
 	Computed: true,
 }
+var floatSchema = &schema.Schema{
+	Type:     schema.TypeFloat,
+	Computed: true,
+}
 var strSchema = &schema.Schema{
 	Type:     schema.TypeString,
 	Computed: true,
 }
 var alertProps = map[string]*schema.Schema{
+	"channelid":       strSchema,
+	"enabled":         strSchema,
 	"immediate":       strSchema,
 	"operator":        strSchema,
+	"sensitivity":     strSchema,
 	"terminal":        strSchema,
 	"triggerinterval": strSchema,
 	"triggerlimit":    intSchema,
+	"zscore":          floatSchema,
 }
 
 func dataSourceAlertRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -35,11 +43,11 @@ func dataSourceAlertRead(ctx context.Context, d *schema.ResourceData, m interfac
 	req := newRequestConfig(
 		pc,
 		"GET",
-		fmt.Sprintf("/v1/config/presetalert/%s", id),
+		pc.endpoint("alert.read", id),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 
 	log.Printf("[DEBUG] GET presetalert raw response body %s\n", body)
 	if err != nil {
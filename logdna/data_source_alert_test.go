@@ -47,8 +47,8 @@ func TestDataAlert_BulkChannels(t *testing.T) {
 				Check: resource.ComposeTestCheckFunc(
 					testDataSourceAlertExists("data.logdna_alert.remote"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.#", "2"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.0.%", "7"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.1.%", "7"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.0.%", "11"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.1.%", "11"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.#", "0"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.#", "0"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.#", "0"),
@@ -60,8 +60,8 @@ func TestDataAlert_BulkChannels(t *testing.T) {
 					testDataSourceAlertExists("data.logdna_alert.remote"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "name", "test"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.#", "2"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.0.%", "6"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.1.%", "6"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.0.%", "10"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.1.%", "10"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.#", "0"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.#", "0"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.#", "0"),
@@ -73,8 +73,8 @@ func TestDataAlert_BulkChannels(t *testing.T) {
 					testDataSourceAlertExists("data.logdna_alert.remote"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "name", "test"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.#", "2"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.0.%", "6"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.1.%", "6"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.0.%", "10"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.1.%", "10"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.#", "0"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.#", "0"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.#", "0"),
@@ -86,8 +86,8 @@ func TestDataAlert_BulkChannels(t *testing.T) {
 					testDataSourceAlertExists("data.logdna_alert.remote"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "name", "test"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.#", "2"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.0.%", "9"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.1.%", "9"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.0.%", "13"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.1.%", "13"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.#", "0"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.#", "0"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.#", "0"),
@@ -124,7 +124,7 @@ func TestDataSourceAlert_MultipleChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.0.triggerinterval", "15m"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "email_channel.0.triggerlimit", "15"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.#", "1"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.0.%", "6"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.0.%", "10"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.0.immediate", "false"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.0.key", "Your PagerDuty API key goes here"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.0.operator", "presence"),
@@ -132,7 +132,7 @@ func TestDataSourceAlert_MultipleChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.0.triggerinterval", "15m"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "pagerduty_channel.0.triggerlimit", "15"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.#", "1"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.0.%", "6"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.0.%", "10"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.0.immediate", "false"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.0.operator", "absence"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.0.terminal", "true"),
@@ -140,7 +140,7 @@ func TestDataSourceAlert_MultipleChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.0.triggerlimit", "15"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "slack_channel.0.url", "https://hooks.slack.com/services/identifier/secret"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.#", "1"),
-					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.0.%", "9"),
+					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.0.%", "13"),
 					// The JSON will have newlines per our API which uses JSON.stringify(obj, null, 2) as the value
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.0.bodytemplate", "{\n  \"fields\": {\n    \"description\": \"{{ matches }} matches found for {{ name }}\",\n    \"issuetype\": {\n      \"name\": \"Bug\"\n    },\n    \"project\": {\n      \"key\": \"test\"\n    },\n    \"summary\": \"Alert from {{ name }}\"\n  }\n}"),
 					resource.TestCheckResourceAttr("data.logdna_alert.remote", "webhook_channel.0.headers.%", "2"),
@@ -0,0 +1,55 @@
+package logdna
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const baseAppsUrl = "/v1/config/apps"
+
+// readNameList fetches every page of a list endpoint returning plain names and
+// stores them under key. Empty accounts produce an empty list, never null.
+func readNameList(ctx context.Context, d *schema.ResourceData, pc *providerConfig, uri string, key string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	names := []string{}
+	err := newRequestConfig(pc, "GET", uri, nil).MakePaginatedRequest(ctx, &names)
+	if pc.ignoreUnavailableFeatures && isNotFoundErr(err) {
+		diags = append(diags, unavailableFeatureWarning(key, err))
+		names = []string{}
+	} else if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Cannot read the remote " + key,
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+	log.Printf("[DEBUG] Found %d %s in the account", len(names), key)
+
+	appendError(d.Set(key, names), &diags)
+
+	d.SetId(key)
+	return diags
+}
+
+func dataSourceAppsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pc := m.(*providerConfig)
+	return readNameList(ctx, d, pc, pc.endpoint("apps.list"), "apps")
+}
+
+func dataSourceApps() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceAppsRead,
+		Schema: map[string]*schema.Schema{
+			"apps": {
+				Type:     schema.TypeList,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Computed: true,
+			},
+		},
+	}
+}
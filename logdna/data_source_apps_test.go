@@ -0,0 +1,78 @@
+package logdna
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataApps_Read(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("Returns the apps of every page", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(baseAppsUrl, r.URL.Path, "URL path is correct")
+			page := []string{"last-app"}
+			if r.URL.Query().Get("offset") == "0" {
+				page = make([]string, listPageSize)
+				for i := range page {
+					page[i] = fmt.Sprintf("app-%d", i)
+				}
+			}
+			assert.Nil(json.NewEncoder(w).Encode(page), "No errors")
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := schema.TestResourceDataRaw(t, dataSourceApps().Schema, map[string]interface{}{})
+
+		diags := dataSourceAppsRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal(listPageSize+1, d.Get("apps.#"), "All apps were returned")
+		assert.Equal("app-0", d.Get("apps.0"))
+		assert.Equal("last-app", d.Get(fmt.Sprintf("apps.%d", listPageSize)))
+	})
+
+	t.Run("Handles accounts without any app", func(t *testing.T) {
+		for _, res := range []string{`[]`, `null`} {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := w.Write([]byte(res))
+				assert.Nil(err, "No errors")
+			}))
+
+			pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+			d := schema.TestResourceDataRaw(t, dataSourceApps().Schema, map[string]interface{}{})
+
+			diags := dataSourceAppsRead(context.Background(), d, &pc)
+			assert.False(diags.HasError(), "No errors for %s", res)
+			assert.Equal(0, d.Get("apps.#"), "No apps for %s", res)
+			assert.Equal("apps", d.Id(), "ID is set")
+			ts.Close()
+		}
+	})
+}
+
+func TestDataHosts_Read(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(baseHostsUrl, r.URL.Path, "URL path is correct")
+		_, err := w.Write([]byte(`["host-1","host-2"]`))
+		assert.Nil(err, "No errors")
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	d := schema.TestResourceDataRaw(t, dataSourceHosts().Schema, map[string]interface{}{})
+
+	diags := dataSourceHostsRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal([]interface{}{"host-1", "host-2"}, d.Get("hosts"), "Hosts are returned")
+}
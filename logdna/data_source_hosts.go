@@ -0,0 +1,28 @@
+package logdna
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const baseHostsUrl = "/v1/config/hosts"
+
+func dataSourceHostsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pc := m.(*providerConfig)
+	return readNameList(ctx, d, pc, pc.endpoint("hosts.list"), "hosts")
+}
+
+func dataSourceHosts() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHostsRead,
+		Schema: map[string]*schema.Schema{
+			"hosts": {
+				Type:     schema.TypeList,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Computed: true,
+			},
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package logdna
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const ingestionExclusionsDataSourceID = "ingestion_exclusions"
+
+var exclusionRuleListSchema = map[string]*schema.Schema{
+	"id":     strSchema,
+	"title":  strSchema,
+	"active": {Type: schema.TypeBool, Computed: true},
+	"apps": {
+		Type:     schema.TypeList,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+		Computed: true,
+	},
+	"hosts": {
+		Type:     schema.TypeList,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+		Computed: true,
+	},
+	"query": strSchema,
+}
+
+func dataSourceIngestionExclusionsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	pc := m.(*providerConfig)
+	rules := []exclusionRule{}
+
+	req := newRequestConfig(
+		pc,
+		"GET",
+		pc.endpoint("ingestion_exclusion.list"),
+		nil,
+	)
+	err := req.MakePaginatedRequest(ctx, &rules)
+	if pc.ignoreUnavailableFeatures && isNotFoundErr(err) {
+		diags = append(diags, unavailableFeatureWarning("ingestion exclusion", err))
+		rules = []exclusionRule{}
+	} else if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Cannot read the remote ingestion exclusion rules",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	// NOTE GetRawConfig is used so an explicit `active = false` filter can be
+	//      told apart from an omitted one
+	var active *bool
+	if cfg := d.GetRawConfig(); !cfg.IsNull() && !cfg.GetAttr("active").IsNull() {
+		v := d.Get("active").(bool)
+		active = &v
+	}
+
+	exclusions := filterExclusionRules(rules, active)
+	log.Printf("[DEBUG] %d of %d ingestion exclusion rules matched the filter", len(exclusions), len(rules))
+
+	appendError(d.Set("exclusions", exclusions), &diags)
+
+	d.SetId(ingestionExclusionsDataSourceID)
+	return diags
+}
+
+// filterExclusionRules maps the rules to the schema, keeping only the ones
+// matching the active filter when one is given
+func filterExclusionRules(rules []exclusionRule, active *bool) []interface{} {
+	exclusions := make([]interface{}, 0, len(rules))
+	for _, r := range rules {
+		if active != nil && bool(r.Active) != *active {
+			continue
+		}
+		exclusions = append(exclusions, map[string]interface{}{
+			"id":     string(r.ID),
+			"title":  r.Title,
+			"active": bool(r.Active),
+			"apps":   r.Apps,
+			"hosts":  r.Hosts,
+			"query":  r.Query,
+		})
+	}
+	return exclusions
+}
+
+func dataSourceIngestionExclusions() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceIngestionExclusionsRead,
+		Schema: map[string]*schema.Schema{
+			"active": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"exclusions": {
+				Type: schema.TypeList,
+				Elem: &schema.Resource{
+					Schema: exclusionRuleListSchema,
+				},
+				Computed: true,
+			},
+		},
+	}
+}
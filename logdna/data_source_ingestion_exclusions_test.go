@@ -0,0 +1,157 @@
+package logdna
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataIngestionExclusions_Read(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("Follows pages until a partial page is returned", func(t *testing.T) {
+		var offsets []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(baseIngestionExclusionUrl, r.URL.Path, "URL path is correct")
+			offset := r.URL.Query().Get("offset")
+			offsets = append(offsets, offset)
+
+			count := listPageSize
+			if offset != "0" {
+				count = 2
+			}
+			page := make([]exclusionRule, 0, count)
+			for i := 0; i < count; i++ {
+				page = append(page, exclusionRule{
+					ID:     flexID(fmt.Sprintf("%s-%d", offset, i)),
+					Title:  "rule",
+					Active: i%2 == 0,
+					Query:  "foo",
+				})
+			}
+			assert.Nil(json.NewEncoder(w).Encode(page), "No errors")
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := schema.TestResourceDataRaw(t, dataSourceIngestionExclusions().Schema, map[string]interface{}{})
+
+		diags := dataSourceIngestionExclusionsRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal([]string{"0", fmt.Sprint(listPageSize)}, offsets, "Both pages were requested")
+		assert.Equal(listPageSize+2, d.Get("exclusions.#"), "All rules were returned")
+		assert.Equal(fmt.Sprintf("%d-1", listPageSize), d.Get(fmt.Sprintf("exclusions.%d.id", listPageSize+1)))
+		assert.Equal(ingestionExclusionsDataSourceID, d.Id(), "ID is set")
+	})
+
+	t.Run("Returns an empty list when there are no rules", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte(`[]`))
+			assert.Nil(err, "No errors")
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := schema.TestResourceDataRaw(t, dataSourceIngestionExclusions().Schema, map[string]interface{}{})
+
+		diags := dataSourceIngestionExclusionsRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal(0, d.Get("exclusions.#"), "No rules were returned")
+	})
+
+	t.Run("Surfaces errors from the server", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "0") // Retried without waiting
+			w.WriteHeader(500)
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := schema.TestResourceDataRaw(t, dataSourceIngestionExclusions().Schema, map[string]interface{}{})
+
+		diags := dataSourceIngestionExclusionsRead(context.Background(), d, &pc)
+		assert.True(diags.HasError(), "Expected error")
+		assert.Equal("Cannot read the remote ingestion exclusion rules", diags[0].Summary, "Summary")
+	})
+}
+
+func TestDataIngestionExclusions_UnavailableFeature(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+	}))
+	defer ts.Close()
+
+	t.Run("Errors on a 404 by default", func(t *testing.T) {
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := schema.TestResourceDataRaw(t, dataSourceIngestionExclusions().Schema, map[string]interface{}{})
+
+		diags := dataSourceIngestionExclusionsRead(context.Background(), d, &pc)
+		assert.True(diags.HasError(), "Expected error")
+	})
+
+	t.Run("Returns an empty result with a warning when unavailable features are ignored", func(t *testing.T) {
+		pc := providerConfig{
+			baseURL:                   ts.URL,
+			httpClient:                &http.Client{Timeout: 15 * time.Second},
+			ignoreUnavailableFeatures: true,
+		}
+		d := schema.TestResourceDataRaw(t, dataSourceIngestionExclusions().Schema, map[string]interface{}{})
+
+		diags := dataSourceIngestionExclusionsRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Len(diags, 1, "There was 1 warning")
+		assert.Equal(diag.Warning, diags[0].Severity, "The level is Warning")
+		assert.Equal(
+			"The ingestion exclusion feature is not available for this account, an empty result is returned",
+			diags[0].Summary,
+			"Summary",
+		)
+		assert.Equal(0, d.Get("exclusions.#"), "No rules were returned")
+		assert.Equal(ingestionExclusionsDataSourceID, d.Id(), "ID is set")
+	})
+
+	t.Run("Still errors on other statuses when unavailable features are ignored", func(t *testing.T) {
+		es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(403)
+		}))
+		defer es.Close()
+
+		pc := providerConfig{
+			baseURL:                   es.URL,
+			httpClient:                &http.Client{Timeout: 15 * time.Second},
+			ignoreUnavailableFeatures: true,
+		}
+		d := schema.TestResourceDataRaw(t, dataSourceIngestionExclusions().Schema, map[string]interface{}{})
+
+		diags := dataSourceIngestionExclusionsRead(context.Background(), d, &pc)
+		assert.True(diags.HasError(), "Expected error")
+	})
+}
+
+func TestDataIngestionExclusions_filterExclusionRules(t *testing.T) {
+	assert := assert.New(t)
+	rules := []exclusionRule{
+		{ID: "on", Active: true},
+		{ID: "off", Active: false},
+	}
+	active, inactive := true, false
+
+	assert.Len(filterExclusionRules(rules, nil), 2, "No filter keeps every rule")
+
+	filtered := filterExclusionRules(rules, &active)
+	assert.Len(filtered, 1, "Only active rules are kept")
+	assert.Equal("on", filtered[0].(map[string]interface{})["id"])
+
+	filtered = filterExclusionRules(rules, &inactive)
+	assert.Len(filtered, 1, "Only inactive rules are kept")
+	assert.Equal("off", filtered[0].(map[string]interface{})["id"])
+}
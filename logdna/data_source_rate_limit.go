@@ -0,0 +1,69 @@
+package logdna
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const rateLimitDataSourceID = "rate_limit"
+
+// dataSourceRateLimitRead exports the rate limit reported by the latest
+// response of this run. Data sources are read before the resources, so when no
+// response reported it yet, the account is read to get one. The attributes the
+// API did not send are left null.
+func dataSourceRateLimitRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	pc := m.(*providerConfig)
+
+	latest := pc.rateLimits.get()
+	if latest == (rateLimit{}) {
+		// Any response reports the rate limit, so a failed status is fine
+		req := newRequestConfig(pc, "GET", pc.endpoint("account.read"), nil)
+		_, err := req.MakeRequestWithContext(ctx)
+		var apiErr *APIError
+		if err != nil && !errors.As(err, &apiErr) {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Cannot request the rate limit, its attributes are left empty",
+				Detail:   err.Error(),
+			})
+		}
+		latest = pc.rateLimits.get()
+	}
+	if latest.Limit != nil {
+		appendError(d.Set("limit", *latest.Limit), &diags)
+	}
+	if latest.Remaining != nil {
+		appendError(d.Set("remaining", *latest.Remaining), &diags)
+	}
+	if latest.ResetAt != nil {
+		appendError(d.Set("reset_at", latest.ResetAt.Format(time.RFC3339)), &diags)
+	}
+
+	d.SetId(rateLimitDataSourceID)
+	return diags
+}
+
+func dataSourceRateLimit() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRateLimitRead,
+		Schema: map[string]*schema.Schema{
+			"limit": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"remaining": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"reset_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataRateLimit_Read(t *testing.T) {
+	assert := assert.New(t)
+
+	headers := map[string]string{}
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{
+		baseURL:    ts.URL,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		rateLimits: &rateLimitCapture{},
+	}
+	read := func() *schema.ResourceData {
+		d := schema.TestResourceDataRaw(t, dataSourceRateLimit().Schema, map[string]interface{}{})
+		diags := dataSourceRateLimitRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal(rateLimitDataSourceID, d.Id(), "ID is set")
+		return d
+	}
+
+	t.Run("Leaves the attributes unknown when no rate limit is reported", func(t *testing.T) {
+		d := read()
+		assert.Equal([]string{"/v1/config/account"}, paths, "The account is read to get a rate limit")
+		for _, k := range []string{"limit", "remaining", "reset_at"} {
+			_, ok := d.GetOk(k)
+			assert.False(ok, "%s is not set", k)
+		}
+	})
+
+	t.Run("Requests the rate limit before any other request", func(t *testing.T) {
+		headers = map[string]string{"X-RateLimit-Remaining": "42"}
+		paths = nil
+		pc := providerConfig{
+			baseURL:    ts.URL,
+			httpClient: &http.Client{Timeout: 15 * time.Second},
+			rateLimits: &rateLimitCapture{},
+		}
+		d := schema.TestResourceDataRaw(t, dataSourceRateLimit().Schema, map[string]interface{}{})
+		diags := dataSourceRateLimitRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal(42, d.Get("remaining"), "The rate limit of the account read is exported")
+
+		diags = dataSourceRateLimitRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Len(paths, 1, "The captured rate limit is reused")
+	})
+
+	t.Run("Exports the headers of the latest response", func(t *testing.T) {
+		headers = map[string]string{"X-RateLimit-Limit": "50", "X-RateLimit-Remaining": "12", "X-RateLimit-Reset": "1700000000"}
+		_, err := newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+		assert.Nil(err, "No errors")
+		headers = map[string]string{"RateLimit-Limit": "50", "RateLimit-Remaining": "11", "RateLimit-Reset": "1700000030"}
+		_, err = newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+		assert.Nil(err, "No errors")
+
+		d := read()
+		assert.Equal(50, d.Get("limit"), "limit")
+		assert.Equal(11, d.Get("remaining"), "The latest remaining count is used")
+		assert.Equal("2023-11-14T22:13:50Z", d.Get("reset_at"), "reset_at")
+	})
+
+	t.Run("Keeps the latest capture across responses without the headers", func(t *testing.T) {
+		headers = map[string]string{}
+		_, err := newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+		assert.Nil(err, "No errors")
+
+		assert.Equal(11, read().Get("remaining"), "The previous capture is kept")
+	})
+
+	t.Run("Leaves the attributes which are not sent unknown", func(t *testing.T) {
+		headers = map[string]string{"X-RateLimit-Remaining": "3"}
+		_, err := newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+		assert.Nil(err, "No errors")
+
+		d := read()
+		assert.Equal(3, d.Get("remaining"), "remaining")
+		_, ok := d.GetOk("reset_at")
+		assert.False(ok, "reset_at is not set")
+	})
+}
+
+func TestDataRateLimit_record(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	c := &rateLimitCapture{}
+	c.record(http.Header{"X-Ratelimit-Reset": {"30"}}, now)
+	assert.Equal(now.Add(30*time.Second), *c.get().ResetAt, "Small values are a delay in seconds")
+
+	c.record(http.Header{"X-Ratelimit-Remaining": {"soon"}}, now)
+	assert.NotNil(c.get().ResetAt, "Invalid values are ignored")
+
+	var disabled *rateLimitCapture
+	disabled.record(http.Header{"X-Ratelimit-Remaining": {"1"}}, now)
+	assert.Nil(disabled.get().Remaining, "A nil capture records nothing")
+}
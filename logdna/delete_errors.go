@@ -0,0 +1,68 @@
+package logdna
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// deleteFailures aggregates the deletions which failed during a run when
+// continue_on_delete_error is set. Deletes run in parallel, hence the lock.
+type deleteFailures struct {
+	mu       sync.Mutex
+	failures []string
+}
+
+// add records a failure and returns every failure recorded so far
+func (f *deleteFailures) add(failure string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, failure)
+	return append([]string(nil), f.failures...)
+}
+
+// singletonResets describe what the destroy of the resources configured once
+// per account does, since their DELETE resets the configuration
+var singletonResets = map[string]string{
+	"logdna_archive":       "disables the archiving of the account",
+	"logdna_stream_config": "disables the streaming of the account",
+}
+
+// deleteError surfaces a failed delete as an error, keeping the resource in
+// the state so that it is retried by the next destroy. By default it aborts
+// the run; with continue_on_delete_error the failure is logged and the other
+// deletions proceed, each failure listing all the failures so far so that the
+// last one aggregates them. A delete blocked by confirm_destroy is never
+// aggregated, since the resource was not even attempted to be deleted.
+func (pc *providerConfig) deleteError(d *schema.ResourceData, resourceType string, err error) diag.Diagnostics {
+	if !pc.continueOnDeleteError || isDestroyBlockedErr(err) {
+		diags := diag.FromErr(operationError("deleting", resourceType, d, err))
+		if reset, ok := singletonResets[resourceType]; ok && !isDestroyBlockedErr(err) {
+			diags[0].Detail = fmt.Sprintf(
+				"%s is a singleton of the account: it cannot be deleted, only reset. Destroying it %s. "+
+					"To stop managing it without a reset, remove it from the state with `terraform state rm` instead. "+
+					"To guard it against destroys, set `lifecycle { prevent_destroy = true }`.",
+				resourceType, reset,
+			)
+		}
+		return diags
+	}
+
+	id := d.Id()
+	failure := fmt.Sprintf("%s %s: %s", resourceType, id, err)
+	failures := []string{failure}
+	if pc.deleteFailures != nil {
+		failures = pc.deleteFailures.add(failure)
+	}
+	log.Printf("[ERROR] Continuing past the failed deletion of %s", failure)
+
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("Cannot delete %s %s, it is kept in the state", resourceType, id),
+		Detail:   fmt.Sprintf("%d deletion(s) failed so far:\n- %s", len(failures), strings.Join(failures, "\n- ")),
+	}}
+}
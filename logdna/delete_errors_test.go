@@ -0,0 +1,139 @@
+package logdna
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteErrors_ContinueOnDeleteError(t *testing.T) {
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	deleted := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("DELETE", r.Method, "Method is correct")
+		id := strings.TrimPrefix(r.URL.Path, "/v1/config/view/")
+		if id == "bad" {
+			w.Header().Set("Retry-After", "0") // Retried without waiting
+			w.WriteHeader(500)
+			return
+		}
+		mu.Lock()
+		deleted[id] = true
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	viewData := func(id string) *schema.ResourceData {
+		d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{"name": id})
+		d.SetId(id)
+		return d
+	}
+
+	t.Run("Aborts on the first failure by default", func(t *testing.T) {
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := viewData("bad")
+
+		diags := resourceViewDelete(context.Background(), d, &pc)
+		assert.True(diags.HasError(), "Expected error")
+		assert.Equal("bad", d.Id(), "The resource is kept in the state")
+	})
+
+	t.Run("Continues past failures and aggregates them", func(t *testing.T) {
+		pc := providerConfig{
+			baseURL:               ts.URL,
+			httpClient:            &http.Client{Timeout: 15 * time.Second},
+			continueOnDeleteError: true,
+			deleteFailures:        &deleteFailures{},
+		}
+
+		results := map[string]diag.Diagnostics{}
+		for _, id := range []string{"first", "bad", "last"} {
+			d := viewData(id)
+			diags := resourceViewDelete(context.Background(), d, &pc)
+			if id == "bad" {
+				assert.Equal(id, d.Id(), "The failed resource is kept in the state")
+			} else {
+				assert.Empty(d.Id(), "%s is removed from the state", id)
+			}
+			results[id] = diags
+		}
+
+		assert.True(deleted["first"], "First view was deleted")
+		assert.True(deleted["last"], "Deletion proceeded after the failure")
+		assert.Empty(results["first"], "No diagnostics for successful deletes")
+		assert.Len(results["bad"], 1, "The failure is reported")
+		assert.Equal(diag.Error, results["bad"][0].Severity, "The run still fails")
+		assert.Equal(
+			"Cannot delete logdna_view bad, it is kept in the state",
+			results["bad"][0].Summary,
+			"Summary",
+		)
+		assert.Contains(results["bad"][0].Detail, "1 deletion(s) failed so far", "Failures are aggregated")
+		assert.Contains(results["bad"][0].Detail, "status 500 NOT OK!", "The error is kept")
+
+		diags := resourceViewDelete(context.Background(), viewData("bad"), &pc)
+		assert.Contains(diags[0].Detail, "2 deletion(s) failed so far", "Later failures list the earlier ones")
+	})
+}
+
+func TestDeleteErrors_ConfirmDestroy(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("No request is expected, got %s %s", r.Method, r.URL.Path)
+	}))
+	defer ts.Close()
+	os.Unsetenv(allowDestroyEnv)
+
+	pc := providerConfig{
+		baseURL:               ts.URL,
+		httpClient:            &http.Client{Timeout: 15 * time.Second},
+		confirmDestroy:        true,
+		continueOnDeleteError: true,
+		deleteFailures:        &deleteFailures{},
+	}
+	d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{"name": "test"})
+	d.SetId("abc")
+
+	diags := resourceViewDelete(context.Background(), d, &pc)
+	assert.True(diags.HasError(), "A blocked delete fails despite continue_on_delete_error")
+	assert.Contains(diags[0].Summary, "confirm_destroy is enabled", "The guard is explained")
+	assert.Equal("abc", d.Id(), "The resource is kept in the state")
+}
+
+func TestDeleteErrors_Singletons(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	d := schema.TestResourceDataRaw(t, resourceArchiveConfig().Schema, map[string]interface{}{})
+	d.SetId(archiveConfigID)
+	diags := resourceArchiveConfigDelete(context.Background(), d, &pc)
+	assert.True(diags.HasError(), "Expected error")
+	assert.Contains(diags[0].Summary, "status 400 NOT OK!", "The request error is kept")
+	assert.Contains(diags[0].Detail, "logdna_archive is a singleton of the account: it cannot be deleted, only reset.", "The singleton is explained")
+	assert.Contains(diags[0].Detail, "terraform state rm", "The alternative is given")
+
+	d = schema.TestResourceDataRaw(t, resourceStreamConfig().Schema, map[string]interface{}{})
+	d.SetId(streamConfigID)
+	diags = resourceStreamConfigDelete(context.Background(), d, &pc)
+	assert.Contains(diags[0].Detail, "disables the streaming of the account", "The reset is described")
+
+	d = schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{"name": "test"})
+	d.SetId("abc")
+	diags = resourceViewDelete(context.Background(), d, &pc)
+	assert.Empty(diags[0].Detail, "Other resources have no guidance")
+}
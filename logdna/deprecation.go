@@ -0,0 +1,130 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// deprecationNotices collects the deprecation of the endpoints called by one
+// Terraform operation, as reported by the Sunset (RFC 8594) and Deprecation
+// headers. The requests of an operation may run in parallel, hence the lock.
+type deprecationNotices struct {
+	mu      sync.Mutex
+	notices []string
+}
+
+type deprecationNoticesKey struct{}
+
+// withDeprecationNotices returns a ctx whose requests record their
+// deprecation notices into the returned collector
+func withDeprecationNotices(ctx context.Context) (context.Context, *deprecationNotices) {
+	notices := &deprecationNotices{}
+	return context.WithValue(ctx, deprecationNoticesKey{}, notices), notices
+}
+
+// recordDeprecation adds the notice of a response carrying a Sunset or a
+// Deprecation header to the collector of ctx, if any. It is also logged, so
+// that requests made outside of an operation are not silent.
+func recordDeprecation(ctx context.Context, method string, url string, header http.Header) {
+	notice := deprecationNotice(method, url, header)
+	if notice == "" {
+		return
+	}
+	log.Printf("[WARN] %s", notice)
+
+	notices, ok := ctx.Value(deprecationNoticesKey{}).(*deprecationNotices)
+	if !ok {
+		return
+	}
+	notices.mu.Lock()
+	defer notices.mu.Unlock()
+	for _, n := range notices.notices {
+		if n == notice {
+			return
+		}
+	}
+	notices.notices = append(notices.notices, notice)
+}
+
+// deprecationNotice describes the deprecation reported by the headers of a
+// response, or is empty when the response reports none
+func deprecationNotice(method string, url string, header http.Header) string {
+	sunset := header.Get("Sunset")
+	deprecation := header.Get("Deprecation")
+	if sunset == "" && deprecation == "" {
+		return ""
+	}
+
+	notice := fmt.Sprintf("%s %s is deprecated by the LogDNA API", method, url)
+	if at, ok := deprecationDate(deprecation); ok {
+		notice += " since " + at.Format(time.RFC3339)
+	}
+	if sunset != "" {
+		if at, err := http.ParseTime(sunset); err == nil {
+			notice += " and will stop working on " + at.UTC().Format(time.RFC3339)
+		} else {
+			notice += " and will stop working soon"
+		}
+	}
+	return notice + "; upgrade the provider to a version using the current API"
+}
+
+// deprecationDate parses the Deprecation header, which is either a boolean, a
+// Unix timestamp prefixed with @ or an HTTP date depending on the draft the
+// server implements
+func deprecationDate(value string) (time.Time, bool) {
+	if strings.HasPrefix(value, "@") {
+		if seconds, err := strconv.ParseInt(value[1:], 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC(), true
+		}
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// warnings returns a warning diagnostic per deprecation notice collected
+func (n *deprecationNotices) warnings() diag.Diagnostics {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var diags diag.Diagnostics
+	for _, notice := range n.notices {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "The LogDNA API reports a deprecated endpoint",
+			Detail:   notice,
+		})
+	}
+	return diags
+}
+
+// withDeprecationWarnings wraps the operations of a resource, or of a data
+// source, so that the deprecation notices of their requests are reported as
+// warnings of the operation
+func withDeprecationWarnings(r *schema.Resource) *schema.Resource {
+	wrap := func(op func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		if op == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+			ctx, notices := withDeprecationNotices(ctx)
+			diags := op(ctx, d, m)
+			return append(diags, notices.warnings()...)
+		}
+	}
+	r.CreateContext = wrap(r.CreateContext)
+	r.ReadContext = wrap(r.ReadContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+	return r
+}
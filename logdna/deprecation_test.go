@@ -0,0 +1,71 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecation_SunsetWarning(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Sunset", "Sat, 31 Dec 2033 23:59:59 GMT")
+		fmt.Fprint(w, `{"email": "jane@example.com", "role": "admin"}`)
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	rs := Provider().ResourcesMap["logdna_member"]
+	d := rs.TestResourceData()
+	d.SetId("jane@example.com")
+
+	diags := rs.ReadContext(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal(diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "The LogDNA API reports a deprecated endpoint",
+		Detail: fmt.Sprintf(
+			"GET %s/v1/config/member/jane@example.com is deprecated by the LogDNA API and will stop working on 2033-12-31T23:59:59Z; upgrade the provider to a version using the current API",
+			ts.URL,
+		),
+	}}, diags, "The sunset is reported once")
+	assert.Equal("admin", d.Get("role"), "The resource is still read")
+
+	diags = resourceMemberRead(context.Background(), d, &pc)
+	assert.Empty(diags, "Only the operations of the provider collect the notices")
+}
+
+func TestDeprecation_deprecationNotice(t *testing.T) {
+	assert := assert.New(t)
+
+	for expected, header := range map[string]http.Header{
+		"": {},
+		"GET /v1 is deprecated by the LogDNA API":                                                                          {"Deprecation": {"true"}},
+		"GET /v1 is deprecated by the LogDNA API since 2023-06-30T23:59:59Z":                                               {"Deprecation": {"@1688169599"}},
+		"GET /v1 is deprecated by the LogDNA API since 2023-06-30T23:59:59Z and will stop working on 2024-01-01T00:00:00Z": {"Deprecation": {"Fri, 30 Jun 2023 23:59:59 GMT"}, "Sunset": {"Mon, 01 Jan 2024 00:00:00 GMT"}},
+		"GET /v1 is deprecated by the LogDNA API and will stop working soon":                                               {"Sunset": {"next year"}},
+	} {
+		if expected != "" {
+			expected += "; upgrade the provider to a version using the current API"
+		}
+		assert.Equal(expected, deprecationNotice("GET", "/v1", header), "Notice of %v", header)
+	}
+
+	ctx, notices := withDeprecationNotices(context.Background())
+	for i := 0; i < 3; i++ {
+		recordDeprecation(ctx, "GET", "/v1", http.Header{"Sunset": {"Mon, 01 Jan 2024 00:00:00 GMT"}})
+	}
+	recordDeprecation(ctx, "GET", "/v2", http.Header{})
+	assert.Len(notices.warnings(), 1, "Notices are deduplicated")
+
+	assert.NotPanics(func() {
+		recordDeprecation(context.Background(), "GET", "/v1", http.Header{"Sunset": {"Mon, 01 Jan 2024 00:00:00 GMT"}})
+	}, "Requests outside of an operation only log the notice")
+}
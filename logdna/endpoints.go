@@ -0,0 +1,106 @@
+package logdna
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultEndpoints are the API paths of every resource operation, keyed by
+// "<resource>.<operation>". Placeholders like {id} are filled in the order in
+// which they appear in the default template, so an override may reorder them.
+var defaultEndpoints = map[string]string{
+	"account.read":               "/v1/config/account",
+	"alert.create":               "/v1/config/presetalert",
+	"alert.read":                 "/v1/config/presetalert/{id}",
+	"alert.update":               "/v1/config/presetalert/{id}",
+	"alert.delete":               "/v1/config/presetalert/{id}",
+	"apps.list":                  baseAppsUrl,
+	"archive.create":             "/v1/config/archiving",
+	"archive.read":               "/v1/config/archiving",
+	"archive.update":             "/v1/config/archiving",
+	"archive.delete":             "/v1/config/archiving",
+	"category.create":            "/v1/config/categories/{type}",
+	"category.list":              "/v1/config/categories/{type}",
+	"category.read":              "/v1/config/categories/{type}/{id}",
+	"category.update":            "/v1/config/categories/{type}/{id}",
+	"category.delete":            "/v1/config/categories/{type}/{id}",
+	"group.list":                 "/v1/config/groups",
+	"hosts.list":                 baseHostsUrl,
+	"ingestion_exclusion.create": baseIngestionExclusionUrl,
+	"ingestion_exclusion.list":   baseIngestionExclusionUrl,
+	"ingestion_exclusion.read":   baseIngestionExclusionUrl + "/{id}",
+	"ingestion_exclusion.update": baseIngestionExclusionUrl + "/{id}",
+	"ingestion_exclusion.delete": baseIngestionExclusionUrl + "/{id}",
+	"key.create":                 "/v1/config/keys?type={type}",
+	"key.list":                   "/v1/config/keys?type={type}",
+	"key.read":                   "/v1/config/keys/{id}",
+	"key.update":                 "/v1/config/keys/{id}",
+	"key.delete":                 "/v1/config/keys/{id}",
+	"member.create":              "/v1/config/member",
+	"member.read":                "/v1/config/member/{id}",
+	"member.update":              "/v1/config/member/{id}",
+	"member.delete":              "/v1/config/member/{id}",
+	"stream_config.create":       "/v1/config/stream",
+	"stream_config.read":         "/v1/config/stream",
+	"stream_config.update":       "/v1/config/stream",
+	"stream_config.delete":       "/v1/config/stream",
+	"stream_exclusion.create":    "/v1/config/stream/exclusions",
+	"stream_exclusion.read":      "/v1/config/stream/exclusions/{id}",
+	"stream_exclusion.update":    "/v1/config/stream/exclusions/{id}",
+	"stream_exclusion.delete":    "/v1/config/stream/exclusions/{id}",
+	"view.create":                "/v1/config/view",
+	"view.list":                  "/v1/config/view",
+	"view.read":                  "/v1/config/view/{id}",
+	"view.update":                "/v1/config/view/{id}",
+	"view.delete":                "/v1/config/view/{id}",
+	"view_tags.update":           "/v1/config/view/{id}",
+}
+
+var endpointPlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// validateEndpointOverrides rejects overrides of unknown operations and
+// placeholders the operation does not provide
+func validateEndpointOverrides(overrides map[string]string) error {
+	for key, template := range overrides {
+		def, ok := defaultEndpoints[key]
+		if !ok {
+			keys := make([]string, 0, len(defaultEndpoints))
+			for k := range defaultEndpoints {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return fmt.Errorf("unknown endpoint %q, expected one of: %s", key, strings.Join(keys, ", "))
+		}
+		for _, m := range endpointPlaceholder.FindAllStringSubmatch(template, -1) {
+			if !strings.Contains(def, m[0]) {
+				return fmt.Errorf("endpoint %q does not provide the %s placeholder", key, m[0])
+			}
+		}
+		if !strings.HasPrefix(template, "/") {
+			return fmt.Errorf("endpoint %q must start with a slash (/), got: %s", key, template)
+		}
+	}
+	return nil
+}
+
+// endpoint returns the path of an operation, honoring the endpoint_overrides
+// of the provider. params fill the placeholders of the default template.
+func (pc *providerConfig) endpoint(key string, params ...string) string {
+	def := defaultEndpoints[key]
+	template := def
+	if override, ok := pc.endpointOverrides[key]; ok {
+		template = override
+	}
+
+	values := map[string]string{}
+	for i, m := range endpointPlaceholder.FindAllStringSubmatch(def, -1) {
+		if i < len(params) {
+			values[m[0]] = params[i]
+		}
+	}
+	return endpointPlaceholder.ReplaceAllStringFunc(template, func(p string) string {
+		return values[p]
+	})
+}
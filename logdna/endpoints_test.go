@@ -0,0 +1,64 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpoints_endpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	pc := providerConfig{}
+	assert.Equal("/v1/config/view/abc", pc.endpoint("view.read", "abc"), "Default template is used")
+	assert.Equal("/v1/config/categories/views/123", pc.endpoint("category.read", "views", "123"), "Params fill the placeholders in order")
+	assert.Equal("/v1/config/keys?type=service", pc.endpoint("key.create", "service"), "Query strings are kept")
+
+	pc.endpointOverrides = map[string]string{"category.read": "/gateway/{id}/of/{type}"}
+	assert.Equal("/gateway/123/of/views", pc.endpoint("category.read", "views", "123"), "Overrides can reorder placeholders")
+	assert.Equal("/v1/config/categories/views/123", pc.endpoint("category.update", "views", "123"), "Other operations keep the default")
+}
+
+func TestEndpoints_validateEndpointOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(validateEndpointOverrides(map[string]string{"view.read": "/v2/views/{id}"}), "Valid override")
+
+	err := validateEndpointOverrides(map[string]string{"view.fetch": "/v2/views/{id}"})
+	assert.Error(err, "Unknown operation")
+	assert.Contains(err.Error(), `unknown endpoint "view.fetch"`, "Error message")
+
+	err = validateEndpointOverrides(map[string]string{"view.create": "/v2/views/{id}"})
+	assert.Error(err, "Unknown placeholder")
+	assert.Contains(err.Error(), "does not provide the {id} placeholder", "Error message")
+
+	err = validateEndpointOverrides(map[string]string{"view.read": "v2/views/{id}"})
+	assert.Error(err, "Relative path")
+}
+
+func TestEndpoints_OverriddenPath(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/gateway/logdna/views/abc", r.URL.Path, "The overridden path is requested")
+		fmt.Fprint(w, `{"viewID":"abc","name":"test","query":"test"}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{
+		baseURL:           ts.URL,
+		httpClient:        &http.Client{Timeout: 15 * time.Second},
+		endpointOverrides: map[string]string{"view.read": "/gateway/logdna/views/{id}"},
+	}
+	d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{"name": "test"})
+	d.SetId("abc")
+
+	diags := resourceViewRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("test", d.Get("query"), "The view was read")
+}
@@ -3,12 +3,12 @@ package logdna
 import "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 type exclusionRule struct {
-	ID     string   `json:"id,omitempty"`
-	Title  string   `json:"title"`
-	Active bool     `json:"active"`
-	Apps   []string `json:"apps"`
-	Hosts  []string `json:"hosts"`
-	Query  string   `json:"query"`
+	ID     flexID      `json:"id,omitempty"`
+	Title  string      `json:"title"`
+	Active flexBool    `json:"active"`
+	Apps   flexStrings `json:"apps"`
+	Hosts  flexStrings `json:"hosts"`
+	Query  string      `json:"query"`
 }
 
 var exclusionRuleAtLeastOneOfFields = []string{"apps", "hosts", "query"}
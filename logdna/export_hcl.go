@@ -0,0 +1,134 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// channelSecrets are the channel fields rendered as placeholders on export
+var channelSecrets = map[string]bool{
+	"key": true,
+}
+
+// ExportViewHCL reads the view viewID from the LogDNA API at apiURL, e.g.
+// https://api.logdna.com, and renders it as the configuration of a logdna_view
+// resource named resourceName, e.g. to adopt the views of an existing account.
+// Credentials are rendered as placeholders which must be replaced before
+// applying. It backs the export-view command.
+func ExportViewHCL(ctx context.Context, apiURL string, serviceKey string, viewID string, resourceName string) ([]byte, diag.Diagnostics) {
+	pc := &providerConfig{
+		baseURL:    apiURL,
+		serviceKey: serviceKey,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+	view, err := Do[viewResponse](ctx, newRequestConfig(pc, "GET", pc.endpoint("view.read", viewID), nil))
+	if err != nil {
+		return nil, diag.FromErr(fmt.Errorf("cannot read view %s: %w", viewID, err))
+	}
+	return exportViewHCL(resourceName, view)
+}
+
+// exportViewHCL renders a view returned by the API as the configuration of a
+// logdna_view resource. Credentials are rendered as placeholders.
+func exportViewHCL(resourceName string, view viewResponse) ([]byte, diag.Diagnostics) {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body().AppendNewBlock("resource", []string{"logdna_view", resourceName}).Body()
+
+	body.SetAttributeValue("name", cty.StringVal(view.Name))
+	setStringAttribute(body, "query", view.Query)
+	setStringAttribute(body, "match", view.Match)
+	setListAttribute(body, "apps", view.Apps)
+	setListAttribute(body, "categories", view.Category)
+	setListAttribute(body, "hosts", view.Hosts)
+	setListAttribute(body, "levels", view.Levels)
+	setListAttribute(body, "tags", view.Tags)
+	if len(view.PresetIds) > 0 {
+		// The API copies the channels of the preset alert into the view,
+		// which conflict with presetid in the configuration
+		setStringAttribute(body, "presetid", string(view.PresetIds[0]))
+		return f.Bytes(), nil
+	}
+
+	integrations, diags := view.MapChannelsToSchema()
+	for _, name := range []string{EMAIL, PAGERDUTY, SLACK, WEBHOOK} {
+		for _, channel := range integrations[name] {
+			body.AppendNewline()
+			appendChannelBlock(body, fmt.Sprintf("%s_channel", name), name, channel.(map[string]interface{}))
+		}
+	}
+
+	return f.Bytes(), diags
+}
+
+func appendChannelBlock(body *hclwrite.Body, blockType string, integration string, channel map[string]interface{}) {
+	block := body.AppendNewBlock(blockType, nil).Body()
+
+	keys := make([]string, 0, len(channel))
+	for k := range channel {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// Slack URLs embed the token of the incoming webhook
+	secret := func(k string) bool { return channelSecrets[k] || (integration == SLACK && k == "url") }
+
+	for _, k := range keys {
+		// Server-generated IDs are computed and cannot be configured
+		if k == "channelid" {
+			continue
+		}
+		switch v := channel[k].(type) {
+		case string:
+			if secret(k) && v != "" {
+				v = redacted
+			}
+			setStringAttribute(block, k, v)
+		case int:
+			if v != 0 {
+				block.SetAttributeValue(k, cty.NumberIntVal(int64(v)))
+			}
+		case float64:
+			if v != 0 {
+				block.SetAttributeValue(k, cty.NumberFloatVal(v))
+			}
+		case []string:
+			setListAttribute(block, k, v)
+		case []interface{}:
+			setListAttribute(block, k, listToStrings(v))
+		case map[string]string:
+			if len(v) == 0 {
+				continue
+			}
+			headers := make(map[string]cty.Value, len(v))
+			for name := range v {
+				// Header values usually carry credentials
+				headers[name] = cty.StringVal(redacted)
+			}
+			block.SetAttributeValue(k, cty.MapVal(headers))
+		}
+	}
+}
+
+func setStringAttribute(body *hclwrite.Body, name string, value string) {
+	if value != "" {
+		body.SetAttributeValue(name, cty.StringVal(value))
+	}
+}
+
+func setListAttribute(body *hclwrite.Body, name string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	list := make([]cty.Value, 0, len(values))
+	for _, v := range values {
+		list = append(list, cty.StringVal(v))
+	}
+	body.SetAttributeValue(name, cty.ListVal(list))
+}
@@ -0,0 +1,196 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestExportHCL_View(t *testing.T) {
+	assert := assert.New(t)
+	active := flexBool(false)
+	view := viewResponse{
+		Name:     "Exported \"View\"",
+		Query:    "level:error ${env}",
+		Apps:     []string{"app1", "app2"},
+		Category: []string{"Demo"},
+		Tags:     []string{"tag1"},
+		ViewID:   "abc123",
+		Channels: []channelResponse{
+			{
+				Integration:     EMAIL,
+				AlertID:         "channel-1",
+				Emails:          []interface{}{"test@logdna.com"},
+				Operator:        "presence",
+				Terminal:        true,
+				TriggerInterval: "15m",
+				TriggerLimit:    15,
+				Timezone:        "Pacific/Samoa",
+			},
+			{
+				Integration:     PAGERDUTY,
+				Key:             "pagerduty-secret",
+				Operator:        "presence",
+				TriggerInterval: "30",
+				TriggerLimit:    1,
+				Active:          &active,
+			},
+			{
+				Integration:     WEBHOOK,
+				URL:             "https://yourwebhook/endpoint",
+				Method:          "post",
+				Headers:         map[string]string{"Authentication": "auth_header_value"},
+				BodyTemplate:    `{"message":"Alerts from {{name}}"}`,
+				Operator:        "presence",
+				TriggerInterval: "1h",
+				TriggerLimit:    10,
+			},
+		},
+	}
+
+	src, diags := exportViewHCL("exported", view)
+	assert.False(diags.HasError(), "No errors")
+
+	file, hclDiags := hclsyntax.ParseConfig(src, "view.tf", hcl.InitialPos)
+	assert.False(hclDiags.HasErrors(), "Generated HCL parses: %s", hclDiags)
+
+	resources := file.Body.(*hclsyntax.Body).Blocks
+	assert.Len(resources, 1, "One resource is rendered")
+	assert.Equal("resource", resources[0].Type, "Block type is correct")
+	assert.Equal([]string{"logdna_view", "exported"}, resources[0].Labels, "Labels are correct")
+
+	cfg := hclBodyToConfig(t, resources[0].Body)
+	diags = resourceView().Validate(terraform.NewResourceConfigRaw(cfg))
+	assert.False(diags.HasError(), "Generated HCL matches the schema: %v", diags)
+
+	// Reading the exported configuration back gives the source view
+	d := schema.TestResourceDataRaw(t, resourceView().Schema, cfg)
+	assert.Equal(view.Name, d.Get("name"), "name is kept verbatim")
+	assert.Equal(view.Query, d.Get("query"), "Template sequences are escaped")
+	assert.Equal([]interface{}{"app1", "app2"}, d.Get("apps"), "apps are rendered")
+	assert.Equal([]interface{}{"Demo"}, d.Get("categories"), "categories are rendered")
+	assert.Equal("", d.Get("presetid"), "presetid is omitted")
+
+	assert.Equal([]interface{}{"test@logdna.com"}, d.Get("email_channel.0.emails"), "emails are rendered")
+	assert.Equal("Pacific/Samoa", d.Get("email_channel.0.timezone"), "timezone is rendered")
+	assert.Equal("true", d.Get("email_channel.0.terminal"), "terminal is rendered")
+	assert.Equal(15, d.Get("email_channel.0.triggerlimit"), "triggerlimit is rendered")
+
+	assert.Equal(redacted, d.Get("pagerduty_channel.0.key"), "The PagerDuty key is a placeholder")
+	assert.Equal("false", d.Get("pagerduty_channel.0.enabled"), "enabled is rendered")
+
+	assert.Equal("https://yourwebhook/endpoint", d.Get("webhook_channel.0.url"), "Webhook URLs are kept")
+	assert.Equal(redacted, d.Get("webhook_channel.0.headers.Authentication"), "Header values are placeholders")
+	assert.Equal(`{"message":"Alerts from {{name}}"}`, d.Get("webhook_channel.0.bodytemplate"), "bodytemplate is rendered")
+	assert.NotContains(string(src), "pagerduty-secret", "Secrets are never rendered")
+	assert.NotContains(string(src), "auth_header_value", "Secrets are never rendered")
+}
+
+func TestExportHCL_PresetView(t *testing.T) {
+	assert := assert.New(t)
+	view := viewResponse{
+		Name:      "Preset View",
+		Query:     "level:error",
+		PresetIds: []flexID{"preset-1"},
+		ViewID:    "abc123",
+		Channels: []channelResponse{
+			{
+				Integration:     EMAIL,
+				Emails:          []interface{}{"test@logdna.com"},
+				Operator:        "presence",
+				TriggerInterval: "15m",
+				TriggerLimit:    15,
+			},
+			{
+				Integration:     SLACK,
+				URL:             "https://hooks.slack.com/services/identifier/secret",
+				Operator:        "absence",
+				TriggerInterval: "30",
+				TriggerLimit:    1,
+			},
+		},
+	}
+
+	src, diags := exportViewHCL("preset", view)
+	assert.False(diags.HasError(), "No errors")
+
+	file, hclDiags := hclsyntax.ParseConfig(src, "view.tf", hcl.InitialPos)
+	assert.False(hclDiags.HasErrors(), "Generated HCL parses: %s", hclDiags)
+
+	body := file.Body.(*hclsyntax.Body).Blocks[0].Body
+	assert.Empty(body.Blocks, "The channels copied from the preset alert are omitted")
+	assert.NotContains(string(src), "_channel", "No channel blocks are rendered")
+
+	cfg := hclBodyToConfig(t, body)
+	diags = resourceView().Validate(terraform.NewResourceConfigRaw(cfg))
+	assert.False(diags.HasError(), "Generated HCL matches the schema: %v", diags)
+	assert.Equal("preset-1", cfg["presetid"], "presetid is rendered")
+}
+
+func TestExportHCL_ExportViewHCL(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("the-key", r.Header.Get("servicekey"), "The service key is sent")
+		if r.URL.Path != "/v1/config/view/abc123" {
+			w.WriteHeader(404)
+			return
+		}
+		fmt.Fprint(w, `{"viewID": "abc123", "name": "errors", "query": "level:error"}`)
+	}))
+	defer ts.Close()
+
+	src, diags := ExportViewHCL(context.Background(), ts.URL, "the-key", "abc123", "errors")
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("resource \"logdna_view\" \"errors\" {\n  name  = \"errors\"\n  query = \"level:error\"\n}\n", string(src), "The view is rendered")
+
+	_, diags = ExportViewHCL(context.Background(), ts.URL, "the-key", "missing", "missing")
+	assert.True(diags.HasError(), "Expected error")
+	assert.Contains(diags[0].Summary, "cannot read view missing", "The view is named")
+}
+
+// hclBodyToConfig converts a literal HCL body to the raw configuration format
+// of the SDK, with nested blocks as lists of maps
+func hclBodyToConfig(t *testing.T, body *hclsyntax.Body) map[string]interface{} {
+	cfg := map[string]interface{}{}
+	for name, attr := range body.Attributes {
+		val, diags := attr.Expr.Value(nil)
+		assert.False(t, diags.HasErrors(), "%s is a literal: %s", name, diags)
+		cfg[name] = ctyToConfig(val)
+	}
+	for _, block := range body.Blocks {
+		list, _ := cfg[block.Type].([]interface{})
+		cfg[block.Type] = append(list, hclBodyToConfig(t, block.Body))
+	}
+	return cfg
+}
+
+func ctyToConfig(val cty.Value) interface{} {
+	switch {
+	case val.Type() == cty.String:
+		return val.AsString()
+	case val.Type() == cty.Number:
+		i, _ := val.AsBigFloat().Int64()
+		return int(i)
+	case val.Type().IsMapType() || val.Type().IsObjectType():
+		m := map[string]interface{}{}
+		for k, v := range val.AsValueMap() {
+			m[k] = ctyToConfig(v)
+		}
+		return m
+	default:
+		list := []interface{}{}
+		for _, v := range val.AsValueSlice() {
+			list = append(list, ctyToConfig(v))
+		}
+		return list
+	}
+}
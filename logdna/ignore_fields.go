@@ -0,0 +1,49 @@
+package logdna
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// channelFields are the attributes holding the channels of a view or an alert
+var channelFields = []string{
+	"email_channel",
+	"pagerduty_channel",
+	"slack_channel",
+	"webhook_channel",
+}
+
+// ignoreFieldsSchema lists the fields whose remote changes are not read,
+// out of the given attributes of the resource
+func ignoreFieldsSchema(fields []string) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		Elem: &schema.Schema{
+			Type:         schema.TypeString,
+			ValidateFunc: validation.StringInSlice(fields, false),
+		},
+	}
+}
+
+// ignoredFields snapshots the state of the fields listed in ignore_fields,
+// before a read overwrites them with the remote values
+func ignoredFields(d *schema.ResourceData) map[string]interface{} {
+	prior := map[string]interface{}{}
+	for _, field := range d.Get("ignore_fields").(*schema.Set).List() {
+		prior[field.(string)] = d.Get(field.(string))
+	}
+	return prior
+}
+
+// restoreIgnoredFields sets back the snapshot of ignoredFields, so that the
+// remote changes of these fields produce no diff
+func restoreIgnoredFields(d *schema.ResourceData, prior map[string]interface{}, diags *diag.Diagnostics) {
+	for field, value := range prior {
+		log.Printf("[DEBUG] Ignoring the remote value of %s\n", field)
+		appendError(d.Set(field, value), diags)
+	}
+}
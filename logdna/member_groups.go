@@ -0,0 +1,62 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// normalizeMemberGroups checks the groups of a member against the groups of
+// the account, which the API would otherwise drop silently when misspelled.
+// Names differing from a group only by case or surrounding spaces are
+// normalized to the name of the group, the other unknown names are rejected.
+// The groups are not validated when they cannot be listed.
+func normalizeMemberGroups(ctx context.Context, pc *providerConfig, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return names, nil
+	}
+	req := newRequestConfig(
+		pc,
+		"GET",
+		pc.endpoint("group.list"),
+		nil,
+	)
+	groups, err := Do[[]groupResponse](ctx, req)
+	if err != nil {
+		log.Printf("[WARN] Cannot list the groups, the groups of logdna_member are not validated: %s", err)
+		return names, nil
+	}
+
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		group, ok := findGroup(groups, name)
+		if !ok {
+			known := make([]string, 0, len(groups))
+			for _, g := range groups {
+				known = append(known, g.Name)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("group %q does not exist in the account, expected one of: %s", name, strings.Join(known, ", "))
+		}
+		normalized = append(normalized, group.Name)
+	}
+	return normalized, nil
+}
+
+// findGroup matches the name of a group exactly, or else case-insensitively
+// and ignoring surrounding spaces
+func findGroup(groups []groupResponse, name string) (groupResponse, bool) {
+	for _, group := range groups {
+		if group.Name == name {
+			return group, true
+		}
+	}
+	for _, group := range groups {
+		if strings.EqualFold(group.Name, strings.TrimSpace(name)) {
+			return group, true
+		}
+	}
+	return groupResponse{}, false
+}
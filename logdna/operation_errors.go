@@ -0,0 +1,26 @@
+package logdna
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// operationError wraps err with the operation and the resource it failed for,
+// e.g. `creating logdna_view "prod-errors": ...`, so that the failures of an
+// apply touching many resources can be pinpointed. err is kept unwrappable.
+func operationError(operation string, resourceType string, d *schema.ResourceData, err error) error {
+	if label := resourceLabel(d); label != "" {
+		return fmt.Errorf("%s %s %q: %w", operation, resourceType, label, err)
+	}
+	return fmt.Errorf("%s %s: %w", operation, resourceType, err)
+}
+
+// resourceLabel identifies a resource by its name when it has one, falling
+// back to its ID. It is empty for a resource without a name before creation.
+func resourceLabel(d *schema.ResourceData) string {
+	if name, ok := d.Get("name").(string); ok && name != "" {
+		return name
+	}
+	return d.Id()
+}
@@ -0,0 +1,67 @@
+package logdna
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationErrors_operationError(t *testing.T) {
+	assert := assert.New(t)
+	cause := errors.New("FAKE ERROR")
+
+	d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{"name": "prod-errors"})
+	err := operationError("creating", "logdna_view", d, cause)
+	assert.EqualError(err, `creating logdna_view "prod-errors": FAKE ERROR`, "The name is used")
+	assert.True(errors.Is(err, cause), "The cause is wrapped")
+
+	d = schema.TestResourceDataRaw(t, resourceArchiveConfig().Schema, map[string]interface{}{})
+	assert.EqualError(operationError("creating", "logdna_archive", d, cause), "creating logdna_archive: FAKE ERROR", "Nothing to name the resource by")
+	d.SetId(archiveConfigID)
+	assert.EqualError(operationError("reading", "logdna_archive", d, cause), `reading logdna_archive "archive": FAKE ERROR`, "The ID is used without a name")
+}
+
+func TestOperationErrors_Resources(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0") // Retried without waiting
+		w.WriteHeader(500)
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	viewData := func() *schema.ResourceData {
+		d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{
+			"name":  "prod-errors",
+			"query": "level:error",
+		})
+		d.SetId("abc")
+		return d
+	}
+
+	for operation, crud := range map[string]schema.CreateContextFunc{
+		"creating": resourceViewCreate,
+		"updating": resourceViewUpdate,
+		"deleting": resourceViewDelete,
+	} {
+		diags := crud(context.Background(), viewData(), &pc)
+		assert.True(diags.HasError(), "Expected error")
+		assert.True(
+			strings.HasPrefix(diags[0].Summary, operation+` logdna_view "prod-errors": `),
+			"The summary names the resource: %s", diags[0].Summary,
+		)
+		assert.Contains(diags[0].Summary, "status 500 NOT OK!", "The request error is kept")
+	}
+
+	diags := resourceViewRead(context.Background(), viewData(), &pc)
+	assert.True(diags.HasError(), "Expected error")
+	assert.Equal("Cannot read the remote view resource", diags[0].Summary, "Summary")
+	assert.True(strings.HasPrefix(diags[0].Detail, `reading logdna_view "prod-errors": `), "The detail names the resource: %s", diags[0].Detail)
+}
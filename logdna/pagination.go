@@ -0,0 +1,108 @@
+package logdna
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+const (
+	// listPageSize is the number of entries requested per page from list endpoints
+	listPageSize = 100
+	// defaultMaxPages guards against endpoints which never stop signaling a next page
+	defaultMaxPages = 100
+)
+
+// paginatedPage is the envelope of the list endpoints returning a cursor to
+// their next page. The other list endpoints return a bare JSON array.
+type paginatedPage struct {
+	Data     json.RawMessage `json:"data"`
+	NextPage flexID          `json:"nextPage"`
+}
+
+// MakePaginatedRequest requests consecutive pages of a list endpoint and
+// appends their entries to unmarshalInto, which must point to a slice. Pages
+// are requested with offset/limit query parameters: the offset is the
+// nextPage cursor of the previous page when it returns one, until it is
+// empty, or the number of entries fetched so far for bare arrays, until a page
+// is not full. A failed page fails the whole request with its error.
+func (c *requestConfig) MakePaginatedRequest(ctx context.Context, unmarshalInto interface{}) error {
+	into := reflect.ValueOf(unmarshalInto)
+	if into.Kind() != reflect.Ptr || into.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%s %s, pages must be unmarshaled into a pointer to a slice, got %T", c.method, c.apiURL, unmarshalInto)
+	}
+	results := into.Elem()
+
+	offset, fetched := "0", 0
+	for pages := 1; ; pages++ {
+		if pages > c.MaxPages {
+			return fmt.Errorf("%s %s, stopped after %d pages: the endpoint keeps signaling a next page", c.method, c.apiURL, c.MaxPages)
+		}
+
+		// Each page is a request of its own, so that the fallback host and the
+		// retries apply to every page
+		page := *c
+		page.apiURL = withPageQuery(c.apiURL, offset)
+		if c.fallbackURL != "" {
+			page.fallbackURL = withPageQuery(c.fallbackURL, offset)
+		}
+
+		body, err := page.MakeRequestWithContext(ctx)
+		log.Printf("[DEBUG] %s %s raw response body %s\n", page.method, page.apiURL, body)
+		if err != nil {
+			return err
+		}
+
+		entries, next := json.RawMessage(body), ""
+		isEnvelope := !bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+		if isEnvelope {
+			envelope := paginatedPage{}
+			if err := json.Unmarshal(body, &envelope); err != nil {
+				return c.redact(fmt.Errorf("%s %s, cannot decode the response: %s, %s", page.method, page.apiURL, err, string(body)))
+			}
+			entries, next = envelope.Data, string(envelope.NextPage)
+		}
+
+		chunk := reflect.New(results.Type())
+		if len(entries) > 0 {
+			if err := json.Unmarshal(entries, chunk.Interface()); err != nil {
+				return c.redact(fmt.Errorf("%s %s, cannot decode the response: %s, %s", page.method, page.apiURL, err, string(body)))
+			}
+		}
+		count := chunk.Elem().Len()
+		results.Set(reflect.AppendSlice(results, chunk.Elem()))
+		fetched += count
+
+		if isEnvelope {
+			if next == "" {
+				return nil
+			}
+			offset = next
+			continue
+		}
+		if count < listPageSize {
+			return nil
+		}
+		offset = strconv.Itoa(fetched)
+	}
+}
+
+// withPageQuery sets the offset/limit query parameters of a list URL, keeping
+// its other parameters
+func withPageQuery(apiURL string, offset string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		// Left for the request to report
+		return apiURL
+	}
+	q := u.Query()
+	q.Set("offset", offset)
+	q.Set("limit", strconv.Itoa(listPageSize))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
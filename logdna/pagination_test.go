@@ -0,0 +1,145 @@
+package logdna
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setMaxPages(maxPages int) func(*requestConfig) {
+	return func(req *requestConfig) {
+		req.MaxPages = maxPages
+	}
+}
+
+// namesPage returns count names starting at offset as a JSON array
+func namesPage(offset int, count int) string {
+	names := make([]string, 0, count)
+	for i := offset; i < offset+count; i++ {
+		names = append(names, fmt.Sprintf(`"name-%d"`, i))
+	}
+	return "[" + strings.Join(names, ",") + "]"
+}
+
+func TestPagination_MakePaginatedRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("Follows the nextPage cursor", func(t *testing.T) {
+		var offsets []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			offset := r.URL.Query().Get("offset")
+			offsets = append(offsets, offset)
+			assert.Equal("abc", r.URL.Query().Get("filter"), "The query of the URI is kept")
+			switch offset {
+			case "0":
+				fmt.Fprintf(w, `{"data": %s, "nextPage": "cursor-2"}`, namesPage(0, 2))
+			case "cursor-2":
+				fmt.Fprintf(w, `{"data": %s, "nextPage": 3}`, namesPage(2, 1))
+			default:
+				fmt.Fprintf(w, `{"data": %s, "nextPage": null}`, namesPage(3, 1))
+			}
+		}))
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		names := []string{}
+		err := newRequestConfig(&pc, "GET", "/v1/config/view?filter=abc", nil).MakePaginatedRequest(context.Background(), &names)
+		assert.Nil(err, "No errors")
+		assert.Equal([]string{"0", "cursor-2", "3"}, offsets, "The cursors were followed")
+		assert.Equal([]string{"name-0", "name-1", "name-2", "name-3"}, names, "Every page was accumulated")
+	})
+
+	t.Run("Advances the offset of bare arrays until a page is not full", func(t *testing.T) {
+		var offsets []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			offsets = append(offsets, r.URL.Query().Get("offset"))
+			assert.Equal(strconv.Itoa(listPageSize), r.URL.Query().Get("limit"), "The page size is requested")
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			if offset == 0 {
+				fmt.Fprint(w, namesPage(0, listPageSize))
+				return
+			}
+			fmt.Fprint(w, namesPage(offset, 5))
+		}))
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		names := []string{}
+		err := newRequestConfig(&pc, "GET", "/v1/config/apps", nil).MakePaginatedRequest(context.Background(), &names)
+		assert.Nil(err, "No errors")
+		assert.Equal([]string{"0", strconv.Itoa(listPageSize)}, offsets, "Both pages were requested")
+		assert.Len(names, listPageSize+5, "Every page was accumulated")
+	})
+
+	t.Run("Stops after MaxPages", func(t *testing.T) {
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			fmt.Fprintf(w, `{"data": [], "nextPage": "again"}`)
+		}))
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		names := []string{}
+		err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, setMaxPages(3)).MakePaginatedRequest(context.Background(), &names)
+		assert.EqualError(
+			err,
+			fmt.Sprintf("GET %s/v1/config/view, stopped after 3 pages: the endpoint keeps signaling a next page", ts.URL),
+			"The loop is reported",
+		)
+		assert.Equal(3, requests, "No more pages were requested")
+	})
+
+	t.Run("Fails with the APIError of a page", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("offset") == "next" {
+				w.WriteHeader(403)
+				return
+			}
+			fmt.Fprintf(w, `{"data": ["first"], "nextPage": "next"}`)
+		}))
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		names := []string{}
+		err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakePaginatedRequest(context.Background(), &names)
+		var apiErr *APIError
+		assert.True(errors.As(err, &apiErr), "The APIError is kept")
+		assert.Equal(403, apiErr.StatusCode, "The status of the failed page")
+	})
+
+	t.Run("Uses the injected body reader for every page", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[]`)
+		}))
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		names := []string{}
+		err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, setBodyReader(func(r io.Reader) ([]byte, error) {
+			return nil, errors.New("FAKE ERROR for body reader")
+		})).MakePaginatedRequest(context.Background(), &names)
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "FAKE ERROR for body reader", "The reader error is returned")
+	})
+
+	t.Run("Rejects results which are not a slice", func(t *testing.T) {
+		names := map[string]string{}
+		pc := providerConfig{baseURL: "http://localhost"}
+		err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakePaginatedRequest(context.Background(), &names)
+		assert.EqualError(
+			err,
+			"GET http://localhost/v1/config/view, pages must be unmarshaled into a pointer to a slice, got *map[string]string",
+			"The target is rejected",
+		)
+	})
+}
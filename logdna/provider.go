@@ -1,21 +1,55 @@
 package logdna
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
 	"net/http"
+	neturl "net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"golang.org/x/net/http2"
 )
 
+// providerConfig is shared by every resource, possibly from parallel goroutines.
+// It must not be mutated after providerConfigure returns; any state changing
+// during requests needs its own synchronization.
 type providerConfig struct {
-	serviceKey string
-	baseURL    string
-	httpClient *http.Client
+	serviceKey                string
+	baseURL                   string
+	httpClient                *http.Client
+	ignoreUnavailableFeatures bool
+	retryMessages             []string
+	continueOnDeleteError     bool
+	deleteFailures            *deleteFailures
+	endpointOverrides         map[string]string
+	viewCache                 *viewCache
+	acceptCharset             string
+	strict                    bool
+	traceConnections          bool
+	rateLimits                *rateLimitCapture
+	fallbackHost              string
+	serviceKeyParam           string
+	confirmDestroy            bool
+	requestHeaders            map[string]string
+	responseContentTypes      []string
+	// retryPolicy overrides the default backoff of every request when set
+	retryPolicy *retryPolicy
+	// Optional hooks run around every request, nil by default. They are called
+	// from parallel goroutines and must be safe for concurrent use.
+	beforeRequest beforeRequestHook
+	afterRequest  afterRequestHook
 }
 
 // Provider initializes the schema with a service key and hooks for our resources
 func Provider() *schema.Provider {
-	return &schema.Provider{
+	p := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"servicekey": {
 				Type:     schema.TypeString,
@@ -24,33 +58,402 @@ func Provider() *schema.Provider {
 			"url": {
 				Type:     schema.TypeString,
 				Optional: true,
-				Default:  "https://api.logdna.com",
+			},
+			"region": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "us",
+				ValidateFunc: validation.StringInSlice(regionNames(), false),
+			},
+			"base_path": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"servicekey_query_param": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"fallback_host": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"force_http1": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"force_h2c": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"force_http1", "insecure"},
+			},
+			"insecure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"max_redirects": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultMaxRedirects,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"accept_charset": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"trace_connections": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"log_request_summary": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"ignore_unavailable_features": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"strict": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"continue_on_delete_error": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"confirm_destroy": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"prefetch_views": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"endpoint_overrides": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"request_headers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"http_timeouts": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultRetryMax,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"retry_min_wait": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  defaultRetryWaitMin.String(),
+			},
+			"retry_max_wait": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  defaultRetryWaitMax.String(),
+			},
+			"response_content_types": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
+			"retry_on_error_messages": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotEmpty,
+				},
 			},
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"logdna_alert": dataSourceAlert(),
+			"logdna_account":              dataSourceAccount(),
+			"logdna_alert":                dataSourceAlert(),
+			"logdna_apps":                 dataSourceApps(),
+			"logdna_hosts":                dataSourceHosts(),
+			"logdna_ingestion_exclusions": dataSourceIngestionExclusions(),
+			"logdna_rate_limit":           dataSourceRateLimit(),
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"logdna_alert":               resourceAlert(),
 			"logdna_view":                resourceView(),
+			"logdna_view_tags":           resourceViewTags(),
 			"logdna_category":            resourceCategory(),
 			"logdna_stream_config":       resourceStreamConfig(),
 			"logdna_stream_exclusion":    resourceStreamExclusion(),
 			"logdna_ingestion_exclusion": resourceIngestionExclusion(),
 			"logdna_archive":             resourceArchiveConfig(),
 			"logdna_key":                 resourceKey(),
+			"logdna_member":              resourceMember(),
+			"logdna_raw":                 resourceRaw(),
 		},
 		ConfigureFunc: providerConfigure,
 	}
+	for _, r := range p.DataSourcesMap {
+		withDeprecationWarnings(r)
+	}
+	for _, r := range p.ResourcesMap {
+		withDeprecationWarnings(withRetryOverride(r))
+	}
+	return p
 }
 
 func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	serviceKey := d.Get("servicekey").(string)
 	url := d.Get("url").(string)
+	if url == "" {
+		url = regionURLs[d.Get("region").(string)]
+	}
+	opts := httpClientOptions{
+		forceHTTP1:   d.Get("force_http1").(bool),
+		forceH2C:     d.Get("force_h2c").(bool),
+		insecure:     d.Get("insecure").(bool),
+		maxRedirects: d.Get("max_redirects").(int),
+	}
+	endpointOverrides := map[string]string{}
+	for k, v := range d.Get("endpoint_overrides").(map[string]interface{}) {
+		endpointOverrides[k] = v.(string)
+	}
+	if err := validateEndpointOverrides(endpointOverrides); err != nil {
+		return nil, err
+	}
+	policy, err := newRetryPolicy(
+		d.Get("max_retries").(int),
+		d.Get("retry_min_wait").(string),
+		d.Get("retry_max_wait").(string),
+	)
+	if err != nil {
+		return nil, err
+	}
+	timeouts, err := parsePhaseTimeouts(d.Get("http_timeouts").(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	opts.timeouts = timeouts
+	responseContentTypes := listToStrings(d.Get("response_content_types").([]interface{}))
+	if len(responseContentTypes) == 0 {
+		responseContentTypes = defaultResponseContentTypes
+	}
+	requestHeaders := map[string]string{}
+	for k, v := range d.Get("request_headers").(map[string]interface{}) {
+		requestHeaders[k] = v.(string)
+	}
+	if err := validateRequestHeaders(requestHeaders); err != nil {
+		return nil, err
+	}
+	if socketPath, ok := unixSocketPath(url); ok {
+		opts.socketPath = socketPath
+		url = unixSocketBaseURL
+	}
+	if err := validateBaseURL(url); err != nil {
+		return nil, err
+	}
+	if opts.forceH2C && !strings.HasPrefix(url, "http://") {
+		return nil, fmt.Errorf("force_h2c requires a plaintext http:// or unix:// url, got: %s", url)
+	}
+
+	if opts.insecure {
+		log.Printf("[WARN] ############################################################")
+		log.Printf("[WARN] insecure = true: TLS certificates of %s are NOT verified.", url)
+		log.Printf("[WARN] This is only meant for testing against local mock servers.")
+		log.Printf("[WARN] ############################################################")
+	}
+
+	serviceKeyParam := d.Get("servicekey_query_param").(string)
+	if serviceKeyParam != "" {
+		log.Printf("[WARN] servicekey_query_param = %q: the service key is also sent in the query string of every request.", serviceKeyParam)
+		log.Printf("[WARN] Query strings may be logged by proxies; only use it with gateways that cannot forward the servicekey header.")
+	}
+
+	pc := &providerConfig{
+		serviceKey:                serviceKey,
+		baseURL:                   joinBasePath(url, d.Get("base_path").(string)),
+		httpClient:                newHTTPClient(opts),
+		ignoreUnavailableFeatures: d.Get("ignore_unavailable_features").(bool),
+		retryMessages:             listToStrings(d.Get("retry_on_error_messages").([]interface{})),
+		continueOnDeleteError:     d.Get("continue_on_delete_error").(bool),
+		deleteFailures:            &deleteFailures{},
+		endpointOverrides:         endpointOverrides,
+		acceptCharset:             d.Get("accept_charset").(string),
+		strict:                    d.Get("strict").(bool),
+		traceConnections:          d.Get("trace_connections").(bool),
+		rateLimits:                &rateLimitCapture{},
+		fallbackHost:              joinBasePath(d.Get("fallback_host").(string), d.Get("base_path").(string)),
+		serviceKeyParam:           serviceKeyParam,
+		confirmDestroy:            d.Get("confirm_destroy").(bool),
+		requestHeaders:            requestHeaders,
+		responseContentTypes:      responseContentTypes,
+		retryPolicy:               policy,
+	}
+	if d.Get("prefetch_views").(bool) {
+		pc.viewCache = &viewCache{}
+	}
+	if d.Get("log_request_summary").(bool) {
+		pc.afterRequest = (&requestDurations{interval: summaryLogInterval}).afterRequest
+	}
+	return pc, nil
+}
+
+// httpClientOptions are the provider settings affecting the HTTP transport
+type httpClientOptions struct {
+	// forceHTTP1 disables HTTP/2 to troubleshoot gateways that mishandle it
+	forceHTTP1 bool
+	// forceH2C speaks HTTP/2 over cleartext (h2c) with prior knowledge, for
+	// service mesh proxies listening on a plaintext host
+	forceH2C bool
+	// insecure skips the TLS certificate verification, for testing only
+	insecure bool
+	// socketPath connects to a Unix domain socket instead of the host of the URL
+	socketPath string
+	// timeouts override the default timeouts of the phases of a request
+	timeouts phaseTimeouts
+	// maxRedirects bounds the redirects followed by a request
+	maxRedirects int
+}
+
+// defaultMaxRedirects is enough for a gateway redirecting to the API host,
+// while failing redirect loops fast
+const defaultMaxRedirects = 3
+
+const unixSocketScheme = "unix://"
+
+// unixSocketBaseURL is the base of the request URLs when connecting through a
+// socket; its host is ignored by the dialer but still sent in the Host header.
+const unixSocketBaseURL = "http://localhost"
+
+// regionURLs are the API hosts of the LogDNA instances, used when url is not set
+var regionURLs = map[string]string{
+	"us": "https://api.logdna.com",
+	"eu": "https://api.eu.logdna.com",
+}
+
+func regionNames() []string {
+	names := make([]string, 0, len(regionURLs))
+	for name := range regionURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateBaseURL rejects a url missing its scheme, e.g. api.logdna.com, which
+// would otherwise only fail on the first request
+func validateBaseURL(baseURL string) error {
+	parsed, err := neturl.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("url must be an absolute URL including its scheme, e.g. https://api.logdna.com, got: %q", baseURL)
+	}
+	return nil
+}
+
+// joinBasePath inserts basePath between the host and the paths of the
+// requests, leaving exactly one slash between them
+func joinBasePath(host string, basePath string) string {
+	host = strings.TrimRight(host, "/")
+	if basePath = strings.Trim(basePath, "/"); basePath == "" || host == "" {
+		return host
+	}
+	return host + "/" + basePath
+}
 
-	return &providerConfig{
-		serviceKey: serviceKey,
-		baseURL:    url,
-		httpClient: &http.Client{Timeout: 15 * time.Second},
-	}, nil
+// unixSocketPath returns the path of the socket when url is unix:///path/to.sock
+func unixSocketPath(url string) (string, bool) {
+	if !strings.HasPrefix(url, unixSocketScheme) {
+		return "", false
+	}
+	return strings.TrimPrefix(url, unixSocketScheme), true
+}
+
+// newHTTPClient builds the client used for every API request. HTTP/2 is
+// negotiated over TLS when the server supports it, unless forceHTTP1 is set.
+func newHTTPClient(opts httpClientOptions) *http.Client {
+	if opts.forceH2C {
+		return newH2CClient(opts)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	// Opt-in only, for mock servers with self-signed certificates
+	transport.TLSClientConfig.InsecureSkipVerify = opts.insecure
+	if opts.forceHTTP1 {
+		transport.ForceAttemptHTTP2 = false
+		// A non-nil, empty map disables the HTTP/2 upgrade during TLS negotiation
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
+	}
+	dialer := &net.Dialer{Timeout: opts.timeouts.dialTimeout(), KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	if opts.socketPath != "" {
+		// Sidecar proxies listen on a local socket, whatever the host of the request
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", opts.socketPath)
+		}
+	}
+	if opts.timeouts.tls > 0 {
+		transport.TLSHandshakeTimeout = opts.timeouts.tls
+	}
+	transport.ResponseHeaderTimeout = opts.timeouts.headers
+
+	return &http.Client{
+		Timeout:       opts.timeouts.requestTimeout(),
+		Transport:     transport,
+		CheckRedirect: opts.checkRedirect,
+	}
+}
+
+// checkRedirect stops following the redirects of a request beyond
+// maxRedirects, e.g. when a gateway redirects in a loop
+func (opts httpClientOptions) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > opts.maxRedirects {
+		return fmt.Errorf(
+			"stopped after %d redirects, before following the next one to %s: the limit is set by max_redirects (%d)",
+			len(via)-1, req.URL.Redacted(), opts.maxRedirects,
+		)
+	}
+	return nil
+}
+
+// newH2CClient builds a client speaking HTTP/2 without TLS. The connection is
+// plaintext whatever the scheme of the URL, so the url is checked beforehand.
+// Only the connect and request timeouts apply, there is no TLS handshake.
+func newH2CClient(opts httpClientOptions) *http.Client {
+	dialer := &net.Dialer{Timeout: opts.timeouts.dialTimeout()}
+	return &http.Client{
+		Timeout:       opts.timeouts.requestTimeout(),
+		CheckRedirect: opts.checkRedirect,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			// Called for http:// URLs too when AllowHTTP is set; no TLS handshake is made
+			DialTLS: func(_, addr string, _ *tls.Config) (net.Conn, error) {
+				if opts.socketPath != "" {
+					return dialer.Dial("unix", opts.socketPath)
+				}
+				return dialer.Dial("tcp", addr)
+			},
+		},
+	}
 }
@@ -1,10 +1,21 @@
 package logdna
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 var serviceKey = os.Getenv("SERVICE_KEY")
@@ -29,3 +40,301 @@ func TestProvider(t *testing.T) {
 func TestProvider_impl(t *testing.T) {
 	var _ *schema.Provider = Provider()
 }
+
+func TestProvider_newHTTPClient(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}))
+	ts.EnableHTTP2 = true
+	// Offer both protocols so that clients refusing HTTP/2 can still connect
+	ts.TLS = &tls.Config{NextProtos: []string{"h2", "http/1.1"}}
+	ts.StartTLS()
+	defer ts.Close()
+
+	protoFor := func(forceHTTP1 bool) string {
+		c := newHTTPClient(httpClientOptions{forceHTTP1: forceHTTP1})
+		// Trust the certificate of the test server only
+		c.Transport.(*http.Transport).TLSClientConfig.RootCAs = ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: c}
+		body, err := newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+		assert.Nil(err, "No errors")
+		return string(body)
+	}
+
+	assert.Equal("HTTP/2.0", protoFor(false), "HTTP/2 is negotiated by default")
+	assert.Equal("HTTP/1.1", protoFor(true), "HTTP/1.1 is used when forced")
+}
+
+func TestProvider_newHTTPClientInsecure(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: newHTTPClient(httpClientOptions{})}
+	_, err := newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+	assert.Error(err, "Self-signed certificates are rejected by default")
+	assert.Contains(err.Error(), "certificate", "Error is about the certificate")
+
+	pc.httpClient = newHTTPClient(httpClientOptions{insecure: true})
+	body, err := newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+	assert.Nil(err, "Self-signed certificates are accepted with insecure")
+	assert.Equal(`{}`, string(body), "Body is returned")
+}
+
+func TestProvider_insecureDefault(t *testing.T) {
+	assert.Equal(t, false, Provider().Schema["insecure"].Default, "insecure is never the default")
+}
+
+func TestProvider_newHTTPClientH2C(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}), &http2.Server{}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: newHTTPClient(httpClientOptions{forceH2C: true})}
+	body, err := newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Equal("HTTP/2.0", string(body), "h2c is used against a plaintext host")
+
+	pc.httpClient = newHTTPClient(httpClientOptions{})
+	body, err = newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Equal("HTTP/1.1", string(body), "Plaintext hosts use HTTP/1.1 by default")
+}
+
+func TestProvider_forceH2CRequiresPlaintextURL(t *testing.T) {
+	assert := assert.New(t)
+	for url, valid := range map[string]bool{
+		"http://mesh.local:8080": true,
+		"unix:///tmp/api.sock":   true,
+		"https://api.logdna.com": false,
+	} {
+		d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+			"servicekey": "key",
+			"url":        url,
+			"force_h2c":  true,
+		})
+		_, err := providerConfigure(d)
+		if valid {
+			assert.Nil(err, "%s is accepted", url)
+		} else {
+			assert.EqualError(err, "force_h2c requires a plaintext http:// or unix:// url, got: "+url)
+		}
+	}
+}
+
+func TestProvider_newHTTPClientUnixSocket(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "logdna-socket")
+	assert.Nil(err, "No errors")
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "api.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	assert.Nil(err, "No errors")
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/v1/config/view", r.URL.Path, "Path is kept")
+		fmt.Fprint(w, `{"viewID":"abc"}`)
+	}))
+	ts.Listener = listener
+	ts.Start()
+	defer ts.Close()
+
+	path, ok := unixSocketPath("unix://" + socketPath)
+	assert.True(ok, "unix:// URLs are recognized")
+	assert.Equal(socketPath, path, "Socket path is parsed")
+	_, ok = unixSocketPath("https://api.logdna.com")
+	assert.False(ok, "Other URLs are left to TCP")
+
+	pc := providerConfig{
+		baseURL:    unixSocketBaseURL,
+		httpClient: newHTTPClient(httpClientOptions{socketPath: path}),
+	}
+	body, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Equal(`{"viewID":"abc"}`, string(body), "Request went through the socket")
+}
+
+func TestProvider_regionAndBasePath(t *testing.T) {
+	assert := assert.New(t)
+	configure := func(raw map[string]interface{}) (*providerConfig, error) {
+		raw["servicekey"] = "key"
+		pc, err := providerConfigure(schema.TestResourceDataRaw(t, Provider().Schema, raw))
+		if err != nil {
+			return nil, err
+		}
+		return pc.(*providerConfig), nil
+	}
+
+	for expected, raw := range map[string]map[string]interface{}{
+		"https://api.logdna.com/v1/config/view":           {},
+		"https://api.eu.logdna.com/v1/config/view":        {"region": "eu"},
+		"https://logdna.example.com/v1/config/view":       {"region": "eu", "url": "https://logdna.example.com"},
+		"https://x/v1/config/view":                        {"url": "https://x/"},
+		"https://x/gateway/v1/config/view":                {"url": "https://x/", "base_path": "/gateway/"},
+		"https://api.eu.logdna.com/logdna/v1/config/view": {"region": "eu", "base_path": "logdna"},
+	} {
+		pc, err := configure(raw)
+		assert.Nil(err, "No errors for %v", raw)
+		assert.Equal(expected, newRequestConfig(pc, "GET", "/v1/config/view", nil).apiURL, "URL of %v", raw)
+	}
+
+	pc, err := configure(map[string]interface{}{"url": "https://x/", "base_path": "/v1/"})
+	assert.Nil(err, "No errors")
+	assert.Equal("https://x/v1/config/view", newRequestConfig(pc, "GET", "/config/view", nil).apiURL, "One slash between each segment")
+
+	pc, err = configure(map[string]interface{}{"fallback_host": "https://y/", "base_path": "/v1/"})
+	assert.Nil(err, "No errors")
+	assert.Equal("https://y/v1/config/view", newRequestConfig(pc, "GET", "/config/view", nil).fallbackURL, "The fallback host has the base path")
+
+	_, err = configure(map[string]interface{}{"url": "api.logdna.com"})
+	assert.EqualError(err, `url must be an absolute URL including its scheme, e.g. https://api.logdna.com, got: "api.logdna.com"`, "The scheme is required")
+
+	_, errs := Provider().Schema["region"].ValidateFunc("ap", "region")
+	assert.Len(errs, 1, "Unknown regions are rejected")
+}
+
+func TestProvider_retryPolicy(t *testing.T) {
+	assert := assert.New(t)
+	configure := func(raw map[string]interface{}) (*providerConfig, error) {
+		raw["servicekey"] = "key"
+		pc, err := providerConfigure(schema.TestResourceDataRaw(t, Provider().Schema, raw))
+		if err != nil {
+			return nil, err
+		}
+		return pc.(*providerConfig), nil
+	}
+
+	pc, err := configure(map[string]interface{}{})
+	assert.Nil(err, "No errors")
+	req := newRequestConfig(pc, "GET", "/v1/config/view", nil)
+	assert.Equal(defaultRetryMax, req.RetryMax, "The default retry count is kept")
+	assert.Equal(defaultRetryWaitMin, req.RetryWaitMin, "The default minimum wait is kept")
+	assert.Equal(defaultRetryWaitMax, req.RetryWaitMax, "The default maximum wait is kept")
+
+	t.Run("Resources inherit the provider defaults", func(t *testing.T) {
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(503)
+		}))
+		defer ts.Close()
+
+		pc, err := configure(map[string]interface{}{
+			"url":            ts.URL,
+			"max_retries":    2,
+			"retry_min_wait": "1ms",
+			"retry_max_wait": "2ms",
+		})
+		assert.Nil(err, "No errors")
+
+		d := schema.TestResourceDataRaw(t, resourceMember().Schema, map[string]interface{}{})
+		d.SetId("jane@example.com")
+		diags := resourceMemberRead(context.Background(), d, pc)
+		assert.True(diags.HasError(), "The last error is reported")
+		assert.Equal(3, requests, "The request and 2 retries were sent")
+	})
+
+	t.Run("Resources can override the provider retries", func(t *testing.T) {
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(503)
+		}))
+		defer ts.Close()
+
+		pc, err := configure(map[string]interface{}{
+			"url":            ts.URL,
+			"max_retries":    2,
+			"retry_min_wait": "1ms",
+			"retry_max_wait": "2ms",
+		})
+		assert.Nil(err, "No errors")
+
+		rs := Provider().ResourcesMap["logdna_member"]
+		for raw, expected := range map[int]int{0: 1, 1: 2, 5: 6} {
+			requests = 0
+			d := schema.TestResourceDataRaw(t, rs.Schema, map[string]interface{}{"max_retries": raw})
+			d.SetId("jane@example.com")
+			diags := rs.ReadContext(context.Background(), d, pc)
+			assert.True(diags.HasError(), "The last error is reported")
+			assert.Equal(expected, requests, "max_retries = %d is used instead of the provider setting", raw)
+		}
+
+		requests = 0
+		d := schema.TestResourceDataRaw(t, rs.Schema, map[string]interface{}{})
+		d.SetId("jane@example.com")
+		rs.ReadContext(context.Background(), d, pc)
+		assert.Equal(3, requests, "Resources without max_retries inherit the provider setting")
+	})
+
+	t.Run("Retries can be disabled", func(t *testing.T) {
+		pc, err := configure(map[string]interface{}{"max_retries": 0})
+		assert.Nil(err, "No errors")
+		assert.Equal(0, newRequestConfig(pc, "GET", "/v1/config/view", nil).RetryMax, "No retries")
+	})
+
+	_, err = configure(map[string]interface{}{"retry_min_wait": "1m", "retry_max_wait": "30s"})
+	assert.EqualError(err, "retry_min_wait (1m0s) cannot be longer than retry_max_wait (30s)", "Inverted waits are rejected")
+
+	_, err = configure(map[string]interface{}{"retry_max_wait": "30"})
+	assert.EqualError(err, `retry_max_wait must be a duration like "30s", got: "30"`, "Durations need a unit")
+
+	_, errs := Provider().Schema["max_retries"].ValidateFunc(-1, "max_retries")
+	assert.Len(errs, 1, "Negative retry counts are rejected")
+}
+
+func TestProvider_maxRedirects(t *testing.T) {
+	assert := assert.New(t)
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/v1/config/view" {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		// Every hop redirects to the next one, forever
+		http.Redirect(w, r, fmt.Sprintf("/hop/%d", requests), http.StatusFound)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: newHTTPClient(httpClientOptions{maxRedirects: defaultMaxRedirects})}
+	_, err := newRequestConfig(&pc, "GET", "/hop/0", nil).MakeRequest()
+	assert.Error(err, "Expected error")
+	assert.Contains(
+		err.Error(),
+		fmt.Sprintf("stopped after 3 redirects, before following the next one to %s/hop/4: the limit is set by max_redirects (3)", ts.URL),
+		"The loop is reported",
+	)
+	assert.Equal(4, requests, "The request and 3 redirects were sent")
+
+	requests = 0
+	pc.httpClient = newHTTPClient(httpClientOptions{maxRedirects: 0})
+	_, err = newRequestConfig(&pc, "GET", "/hop/0", nil).MakeRequest()
+	assert.Error(err, "Expected error")
+	assert.Contains(err.Error(), "stopped after 0 redirects", "Redirects can be disabled")
+	assert.Equal(1, requests, "No redirect is followed")
+
+	redirectOnce := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/config/view" {
+			fmt.Fprint(w, `{"viewID": "abc"}`)
+			return
+		}
+		http.Redirect(w, r, "/v1/config/view", http.StatusMovedPermanently)
+	}))
+	defer redirectOnce.Close()
+	pc = providerConfig{baseURL: redirectOnce.URL, httpClient: newHTTPClient(httpClientOptions{maxRedirects: 1})}
+	body, err := newRequestConfig(&pc, "GET", "/gateway/view", nil).MakeRequest()
+	assert.Nil(err, "Redirects within the limit are followed")
+	assert.Equal(`{"viewID": "abc"}`, string(body))
+}
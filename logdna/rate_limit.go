@@ -0,0 +1,79 @@
+package logdna
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitHeaders are the names the rate limit may be reported under, the
+// draft standard ones first
+var rateLimitHeaders = map[string][]string{
+	"limit":     {"RateLimit-Limit", "X-RateLimit-Limit"},
+	"remaining": {"RateLimit-Remaining", "X-RateLimit-Remaining"},
+	"reset":     {"RateLimit-Reset", "X-RateLimit-Reset"},
+}
+
+// rateLimit is the rate limit reported by a response; a nil field was not sent
+type rateLimit struct {
+	Limit     *int
+	Remaining *int
+	ResetAt   *time.Time
+}
+
+// rateLimitCapture keeps the rate limit of the latest response reporting one.
+// Requests run in parallel, hence the lock.
+type rateLimitCapture struct {
+	mu     sync.Mutex
+	latest rateLimit
+}
+
+// record captures the rate limit headers of a response received at now.
+// Responses without any of them leave the previous capture untouched.
+func (c *rateLimitCapture) record(header http.Header, now time.Time) {
+	if c == nil {
+		return
+	}
+	limit := headerInt(header, rateLimitHeaders["limit"])
+	remaining := headerInt(header, rateLimitHeaders["remaining"])
+	reset := headerInt(header, rateLimitHeaders["reset"])
+	if limit == nil && remaining == nil && reset == nil {
+		return
+	}
+
+	captured := rateLimit{Limit: limit, Remaining: remaining}
+	if reset != nil {
+		resetAt := now.Add(time.Duration(*reset) * time.Second)
+		// Large values are a Unix timestamp rather than a delay in seconds
+		if *reset > 1e9 {
+			resetAt = time.Unix(int64(*reset), 0)
+		}
+		resetAt = resetAt.UTC()
+		captured.ResetAt = &resetAt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.latest = captured
+}
+
+// get returns the latest rate limit captured
+func (c *rateLimitCapture) get() rateLimit {
+	if c == nil {
+		return rateLimit{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.latest
+}
+
+// headerInt returns the first of names sent as an integer
+func headerInt(header http.Header, names []string) *int {
+	for _, name := range names {
+		if v, err := strconv.Atoi(header.Get(name)); err == nil {
+			return &v
+		}
+	}
+	return nil
+}
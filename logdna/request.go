@@ -2,20 +2,52 @@ package logdna
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
+	"mime"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/logging"
+)
+
+const (
+	// maxMessageRetries bounds the retries of 200 responses with a retryable error body
+	maxMessageRetries = 3
+	messageRetryWait  = time.Second
+	// The 429 and 5xx responses are retried with an exponential backoff
+	defaultRetryMax     = 4
+	defaultRetryWaitMin = time.Second
+	defaultRetryWaitMax = 30 * time.Second
 )
 
-type httpRequest func(string, string, io.Reader) (*http.Request, error)
+type httpRequest func(context.Context, string, string, io.Reader) (*http.Request, error)
 type bodyReader func(io.Reader) ([]byte, error)
 type jsonMarshal func(interface{}) ([]byte, error)
 type httpClientInterface interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
+// beforeRequestHook is called before every request is sent and may mutate it,
+// e.g. to add headers for auditing
+type beforeRequestHook func(req *http.Request)
+
+// afterRequestHook is called once every request completes, whatever its status.
+// res and body are nil when the request could not be sent.
+type afterRequestHook func(req *http.Request, res *http.Response, body []byte, elapsed time.Duration)
+
 // Configuration for the HTTP client used to make requests to remote resources
 type requestConfig struct {
 	serviceKey  string
@@ -26,19 +58,123 @@ type requestConfig struct {
 	httpRequest httpRequest
 	bodyReader  bodyReader
 	jsonMarshal jsonMarshal
+	// Substrings of a 200 response body which mean it should be retried
+	retryMessages []string
+	retryWait     time.Duration
+	// RetryMax bounds the retries of 429 and 5xx responses, which wait from
+	// RetryWaitMin, doubled on every retry up to RetryWaitMax, unless the
+	// response sends Retry-After
+	RetryMax     int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+	// MaxPages bounds the pages followed by MakePaginatedRequest
+	MaxPages      int
+	beforeRequest beforeRequestHook
+	afterRequest  afterRequestHook
+	// deadline bounds the total time spent in MakeRequest, including retries
+	deadline time.Time
+	// logBody logs an indented and redacted copy of the request body
+	logBody bool
+	// fallbackURL is tried once when the host of apiURL cannot be reached
+	fallbackURL string
+	// traceConnections logs whether each request reused a connection
+	traceConnections bool
+	// rateLimits captures the rate limit headers of every response
+	rateLimits *rateLimitCapture
+	// strict fails the requests whose response carries warnings
+	strict bool
+	// acceptCharset enables the charset negotiation required by some gateways
+	acceptCharset string
+	// serviceKeyParam also sends the service key as this query parameter
+	serviceKeyParam string
+	// confirmDestroy blocks the DELETE requests unless allowDestroyEnv is set
+	confirmDestroy bool
+	// headers are sent with the request, e.g. to opt in to beta API behavior
+	headers map[string]string
+	// contentTypes allowed for the successful responses, unchecked when empty
+	contentTypes []string
+}
+
+// authHeaders authenticate the requests and cannot be set by request_headers
+var authHeaders = []string{"servicekey", "Authorization"}
+
+// validateRequestHeaders rejects the headers which would replace authHeaders
+func validateRequestHeaders(headers map[string]string) error {
+	for name := range headers {
+		for _, auth := range authHeaders {
+			if strings.EqualFold(name, auth) {
+				return fmt.Errorf("request_headers cannot set the %s header, which authenticates the requests", name)
+			}
+		}
+	}
+	return nil
+}
+
+// allowDestroyEnv must be set to 1 for the DELETE requests to be issued when
+// confirm_destroy is enabled
+const allowDestroyEnv = "LOGDNA_ALLOW_DESTROY"
+
+// retryPolicy is the backoff of the 429 and 5xx responses set on the provider,
+// inherited by the requests of every resource
+type retryPolicy struct {
+	retryMax     int
+	retryWaitMin time.Duration
+	retryWaitMax time.Duration
+}
+
+// newRetryPolicy parses the retry settings of the provider
+func newRetryPolicy(retryMax int, waitMin string, waitMax string) (*retryPolicy, error) {
+	minWait, err := time.ParseDuration(waitMin)
+	if err != nil || minWait < 0 {
+		return nil, fmt.Errorf("retry_min_wait must be a duration like \"1s\", got: %q", waitMin)
+	}
+	maxWait, err := time.ParseDuration(waitMax)
+	if err != nil || maxWait < 0 {
+		return nil, fmt.Errorf("retry_max_wait must be a duration like \"30s\", got: %q", waitMax)
+	}
+	if minWait > maxWait {
+		return nil, fmt.Errorf("retry_min_wait (%s) cannot be longer than retry_max_wait (%s)", minWait, maxWait)
+	}
+	return &retryPolicy{retryMax: retryMax, retryWaitMin: minWait, retryWaitMax: maxWait}, nil
 }
 
 // newRequestConfig abstracts the struct creation to allow for mocking
 func newRequestConfig(pc *providerConfig, method string, uri string, body interface{}, mutators ...func(*requestConfig)) *requestConfig {
 	rc := &requestConfig{
-		serviceKey: pc.serviceKey,
-		httpClient: pc.httpClient,
-		apiURL:      fmt.Sprintf("%s%s", pc.baseURL, uri), // uri should have a preceding slash (/)
-		method:      method,
-		body:        body,
-		httpRequest: http.NewRequest,
-		bodyReader:  ioutil.ReadAll,
-		jsonMarshal: json.Marshal,
+		serviceKey:       pc.serviceKey,
+		httpClient:       pc.httpClient,
+		apiURL:           joinURLPath(pc.baseURL, uri),
+		method:           method,
+		body:             body,
+		httpRequest:      http.NewRequestWithContext,
+		bodyReader:       ioutil.ReadAll,
+		jsonMarshal:      json.Marshal,
+		retryMessages:    pc.retryMessages,
+		retryWait:        messageRetryWait,
+		RetryMax:         defaultRetryMax,
+		RetryWaitMin:     defaultRetryWaitMin,
+		RetryWaitMax:     defaultRetryWaitMax,
+		MaxPages:         defaultMaxPages,
+		beforeRequest:    pc.beforeRequest,
+		afterRequest:     pc.afterRequest,
+		logBody:          logging.IsDebugOrHigher(),
+		acceptCharset:    pc.acceptCharset,
+		strict:           pc.strict,
+		traceConnections: pc.traceConnections,
+		rateLimits:       pc.rateLimits,
+		serviceKeyParam:  pc.serviceKeyParam,
+		confirmDestroy:   pc.confirmDestroy,
+		headers:          pc.requestHeaders,
+		contentTypes:     pc.responseContentTypes,
+	}
+
+	if pc.retryPolicy != nil {
+		rc.RetryMax = pc.retryPolicy.retryMax
+		rc.RetryWaitMin = pc.retryPolicy.retryWaitMin
+		rc.RetryWaitMax = pc.retryPolicy.retryWaitMax
+	}
+	if pc.fallbackHost != "" {
+		rc.fallbackURL = joinURLPath(pc.fallbackHost, uri)
 	}
 
 	// Used during testing only; Allow mutations passed in by tests
@@ -48,34 +184,595 @@ func newRequestConfig(pc *providerConfig, method string, uri string, body interf
 	return rc
 }
 
+// joinURLPath appends the path of a request to the base URL, with exactly one
+// slash between them
+func joinURLPath(baseURL string, uri string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(uri, "/")
+}
+
+// MakeRequest is MakeRequestWithContext without cancellation
 func (c *requestConfig) MakeRequest() ([]byte, error) {
+	return c.MakeRequestWithContext(context.Background())
+}
+
+// MakeRequestWithContext sends the request and its retries with ctx, usually
+// the one of the Terraform operation: its deadline bounds the total time spent,
+// and cancelling it, e.g. on Ctrl-C, aborts the call in flight. The service key
+// is masked in the errors returned.
+func (c *requestConfig) MakeRequestWithContext(ctx context.Context) ([]byte, error) {
+	body, err := c.makeRequest(ctx)
+	return body, c.redact(err)
+}
+
+func (c *requestConfig) makeRequest(ctx context.Context) ([]byte, error) {
+	if retryMax, ok := retryMaxOverride(ctx); ok {
+		c.RetryMax = retryMax
+	}
+	if c.confirmDestroy && c.method == "DELETE" && os.Getenv(allowDestroyEnv) != "1" {
+		return nil, &destroyBlockedError{method: c.method, url: c.apiURL}
+	}
+	if deadline, ok := ctx.Deadline(); ok && (c.deadline.IsZero() || deadline.Before(c.deadline)) {
+		c.deadline = deadline
+	}
+	messageRetries, statusRetries := 0, 0
+	for attempt := 1; ; attempt++ {
+		// Redacted before being logged, e.g. when the response reflects the key
+		body, err := c.doRequest(ctx)
+		err = c.redact(err)
+		if err != nil && c.fallbackURL != "" && isConnectionError(err) {
+			// The following attempts stick to the fallback host
+			log.Printf("[WARN] %s %s is unreachable, falling back to %s: %s", c.method, c.apiURL, c.fallbackURL, err)
+			c.apiURL, c.fallbackURL = c.fallbackURL, ""
+			body, err = c.doRequest(ctx)
+			err = c.redact(err)
+		}
+		if err != nil {
+			wait, ok := c.statusRetryWait(err, statusRetries)
+			if !ok || statusRetries >= c.RetryMax {
+				return body, err
+			}
+			if !c.deadline.IsZero() && time.Now().Add(wait).After(c.deadline) {
+				return body, fmt.Errorf("%s %s, deadline exceeded after %d attempts: %w", c.method, c.apiURL, attempt, err)
+			}
+			log.Printf("[WARN] %s %s failed, retrying in %s (attempt %d): %s", c.method, c.apiURL, wait, attempt, err)
+			if waitErr := sleep(ctx, wait); waitErr != nil {
+				return body, fmt.Errorf("%s %s, retry aborted: %w, last error: %s", c.method, c.apiURL, waitErr, err)
+			}
+			statusRetries++
+			continue
+		}
+		if messageRetries >= maxMessageRetries || !c.hasRetryMessage(body) {
+			return c.checkWarnings(body)
+		}
+		if !c.deadline.IsZero() && time.Now().Add(c.retryWait).After(c.deadline) {
+			return nil, fmt.Errorf(
+				"%s %s, deadline exceeded after %d attempts, last response: %s",
+				c.method, c.apiURL, attempt, string(body),
+			)
+		}
+		log.Printf("[WARN] %s %s returned a retryable error (attempt %d): %s", c.method, c.apiURL, attempt, redactSecret(string(body), c.serviceKey))
+		if err := sleep(ctx, c.retryWait); err != nil {
+			return nil, fmt.Errorf("%s %s, retry aborted: %w, last response: %s", c.method, c.apiURL, err, string(body))
+		}
+		messageRetries++
+	}
+}
+
+// sleep waits for d, or returns the error of ctx once it is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// statusRetryWait returns how long to wait before retrying a request which
+// failed with err, after the given number of retries. Only the 429 and 5xx
+// responses are retried. The wait never exceeds RetryWaitMax, even when the
+// response asks for more with Retry-After.
+func (c *requestConfig) statusRetryWait(err error, retries int) (time.Duration, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests && apiErr.StatusCode < http.StatusInternalServerError {
+		return 0, false
+	}
+	if wait, ok := retryAfter(apiErr.Header, time.Now()); ok {
+		// A server asking for a long delay must not stall the apply
+		if wait > c.RetryWaitMax {
+			log.Printf("[WARN] %s %s asks to retry in %s, waiting retry_max_wait (%s) instead", c.method, c.apiURL, wait, c.RetryWaitMax)
+			wait = c.RetryWaitMax
+		}
+		return wait, true
+	}
+	wait := c.RetryWaitMin
+	for i := 0; i < retries && wait < c.RetryWaitMax; i++ {
+		wait *= 2
+	}
+	if wait > c.RetryWaitMax {
+		wait = c.RetryWaitMax
+	}
+	return wait, true
+}
+
+// retryAfter parses the Retry-After header of a response received at now,
+// which is either a delay in seconds or an HTTP date
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// destroyBlockedError is returned by MakeRequest for the DELETE requests
+// blocked by confirm_destroy. The resource still exists remotely, so the
+// delete must fail even with continue_on_delete_error.
+type destroyBlockedError struct {
+	method string
+	url    string
+}
+
+func (e *destroyBlockedError) Error() string {
+	return fmt.Sprintf(
+		"%s %s was blocked: confirm_destroy is enabled, set %s=1 to allow deletes",
+		e.method, e.url, allowDestroyEnv,
+	)
+}
+
+// isDestroyBlockedErr tells whether confirm_destroy blocked the request
+func isDestroyBlockedErr(err error) bool {
+	var blocked *destroyBlockedError
+	return errors.As(err, &blocked)
+}
+
+// APIError is returned by MakeRequest for the responses whose status is not
+// 2xx, so that callers can branch on the status with errors.As
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	Header     http.Header
+	method     string
+	url        string
+}
+
+func (e *APIError) Error() string {
+	if isQuotaError(e.StatusCode, e.Body) {
+		return fmt.Sprintf(
+			"%s %s, status %d NOT OK! The account is over quota or has a billing issue; upgrade the plan or contact LogDNA support. %s",
+			e.method, e.url, e.StatusCode, string(e.Body),
+		)
+	}
+	if e.StatusCode == http.StatusRequestEntityTooLarge {
+		return fmt.Sprintf(
+			"%s %s, status %d NOT OK! The request body is too large for the API; split the resource into smaller ones, e.g. fewer apps, hosts, categories or channels per view, or fewer exclusion rules. %s",
+			e.method, e.url, e.StatusCode, string(e.Body),
+		)
+	}
+	return fmt.Sprintf("%s %s, status %d NOT OK! %s", e.method, e.url, e.StatusCode, string(e.Body))
+}
+
+// Do makes the request with ctx and decodes its JSON response into a T, so
+// callers do not have to unmarshal the bytes returned by MakeRequest themselves
+func Do[T any](ctx context.Context, c *requestConfig) (T, error) {
+	var result T
+	body, err := c.MakeRequestWithContext(ctx)
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, c.redact(fmt.Errorf("%s %s, cannot decode the response: %s, %s", c.method, c.apiURL, err, string(body)))
+	}
+	return result, nil
+}
+
+// connectionTrace logs whether the connection of a request was reused from the
+// pool or newly dialed, e.g. to diagnose applies slowed down by no keep-alive
+func (c *requestConfig) connectionTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				log.Printf("[DEBUG] %s %s, reused connection to %s (idle for %s)", c.method, c.apiURL, info.Conn.RemoteAddr(), info.IdleTime)
+				return
+			}
+			log.Printf("[DEBUG] %s %s, dialed new connection to %s", c.method, c.apiURL, info.Conn.RemoteAddr())
+		},
+	}
+}
+
+// checkWarnings surfaces the warnings array some successful responses carry.
+// They are logged, or fail the request in strict mode so that CI catches them.
+func (c *requestConfig) checkWarnings(body []byte) ([]byte, error) {
+	warnings := responseWarnings(body)
+	if len(warnings) == 0 {
+		return body, nil
+	}
+	if c.strict {
+		return nil, fmt.Errorf(
+			"%s %s, the API returned warnings in strict mode: %s",
+			c.method, c.apiURL, strings.Join(warnings, "; "),
+		)
+	}
+	for _, w := range warnings {
+		log.Printf("[WARN] %s %s returned a warning: %s", c.method, c.apiURL, w)
+	}
+	return body, nil
+}
+
+// responseWarnings returns the warnings of a response body, which are either
+// messages or objects. List responses have none.
+func responseWarnings(body []byte) []string {
+	response := struct {
+		Warnings []json.RawMessage `json:"warnings"`
+	}{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+
+	warnings := make([]string, 0, len(response.Warnings))
+	for _, raw := range response.Warnings {
+		var msg string
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			msg = string(raw)
+		}
+		warnings = append(warnings, msg)
+	}
+	return warnings
+}
+
+// retryableBody holds the fields of a response body which may carry an error
+type retryableBody struct {
+	Error   interface{} `json:"error"`
+	Message string      `json:"message"`
+}
+
+// hasRetryMessage reports whether the error of a successful response body,
+// its error or message field, contains one of the configured transient error
+// messages. The other fields, e.g. the query of a view, are not matched.
+func (c *requestConfig) hasRetryMessage(body []byte) bool {
+	if len(c.retryMessages) == 0 {
+		return false
+	}
+	fields := retryableBody{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return false
+	}
+	msg := strings.ToLower(fields.Message)
+	switch e := fields.Error.(type) {
+	case string:
+		msg += "\n" + strings.ToLower(e)
+	case map[string]interface{}:
+		// e.g. {"error": {"message": "..."}}
+		if m, ok := e["message"].(string); ok {
+			msg += "\n" + strings.ToLower(m)
+		}
+	}
+	for _, m := range c.retryMessages {
+		if m != "" && strings.Contains(msg, strings.ToLower(m)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *requestConfig) doRequest(ctx context.Context) ([]byte, error) {
 	payloadBuf := bytes.NewBuffer([]byte{})
 	if c.body != nil {
 		pbytes, err := c.jsonMarshal(c.body)
 		if err != nil {
 			return nil, err
 		}
+		pbytes = compactBody(pbytes)
 		payloadBuf = bytes.NewBuffer(pbytes)
+		if c.logBody {
+			log.Printf("[DEBUG] %s %s, request body:\n%s", c.method, c.apiURL, debugBody(pbytes))
+		}
 	}
 
-	req, err := c.httpRequest(c.method, c.apiURL, payloadBuf)
+	req, err := c.httpRequest(ctx, c.method, c.apiURL, payloadBuf)
 	if err != nil {
 		return nil, err
 	}
+	if !c.deadline.IsZero() {
+		ctx, cancel := context.WithDeadline(req.Context(), c.deadline)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+	if c.traceConnections {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), c.connectionTrace()))
+	}
+	phases := newPhaseTracker()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), phases.trace()))
+	// Set first so that they cannot replace the headers of the provider
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
 	req.Header.Set("Content-Type", "application/json")
+	if c.acceptCharset != "" {
+		// Bodies are always marshalled as UTF-8
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Accept-Charset", c.acceptCharset)
+	}
 	req.Header.Set("servicekey", c.serviceKey)
+	if c.serviceKeyParam != "" {
+		// Only req.URL carries the key, c.apiURL is what gets logged
+		query := req.URL.Query()
+		query.Set(c.serviceKeyParam, c.serviceKey)
+		req.URL.RawQuery = query.Encode()
+	}
+	if c.beforeRequest != nil {
+		c.beforeRequest(req)
+	}
+	start := time.Now()
 	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error during HTTP request: %s", err)
+		if c.afterRequest != nil {
+			c.afterRequest(req, nil, nil, time.Since(start))
+		}
+		return nil, fmt.Errorf("error during HTTP request: %w", phases.withPhase(err))
 	}
-	defer res.Body.Close()
+	defer func() {
+		// Drained so that the connection is reused, e.g. by the retries
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+	}()
+	c.rateLimits.record(res.Header, time.Now())
+	recordDeprecation(ctx, c.method, c.apiURL, res.Header)
 
-	body, err := c.bodyReader(res.Body)
+	var body []byte
+	reader, err := decodedBody(res)
+	if err == nil {
+		defer reader.Close()
+		body, err = c.bodyReader(reader)
+	}
+	if c.afterRequest != nil {
+		c.afterRequest(req, res, body, time.Since(start))
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error parsing HTTP response: %s, %s", err, string(body))
+		return nil, fmt.Errorf("error parsing HTTP response: %s, %s", phases.withPhase(err), string(body))
 	}
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s %s, status %d NOT OK! %s", c.method, c.apiURL, res.StatusCode, string(body))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, &APIError{
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Body:       body,
+			Header:     res.Header,
+			method:     c.method,
+			url:        c.apiURL,
+		}
+	}
+	if err := c.checkContentType(res.Header, body); err != nil {
+		return nil, err
 	}
 	return body, err
 }
+
+// defaultResponseContentTypes are the media types the LogDNA API responds with
+var defaultResponseContentTypes = []string{"application/json"}
+
+// maxBodyExcerpt bounds the part of an unexpected response body reported
+const maxBodyExcerpt = 200
+
+// checkContentType rejects the successful responses whose media type is not
+// one of contentTypes, before their body is decoded, e.g. the HTML login page
+// of a proxy. Entries like text/* match any subtype. Empty bodies are not
+// checked, nor are responses without a Content-Type.
+func (c *requestConfig) checkContentType(header http.Header, body []byte) error {
+	contentType := header.Get("Content-Type")
+	if len(c.contentTypes) == 0 || len(body) == 0 || contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	for _, allowed := range c.contentTypes {
+		if strings.EqualFold(mediaType, allowed) {
+			return nil
+		}
+		if prefix := strings.TrimSuffix(allowed, "*"); prefix != allowed && strings.HasPrefix(strings.ToLower(mediaType), strings.ToLower(prefix)) {
+			return nil
+		}
+	}
+
+	excerpt := string(body)
+	if len(excerpt) > maxBodyExcerpt {
+		excerpt = excerpt[:maxBodyExcerpt] + "..."
+	}
+	return fmt.Errorf(
+		"%s %s, the response has Content-Type %q, expected one of: %s. A proxy or gateway may have answered instead of the LogDNA API, e.g. with a login page; otherwise add the type to response_content_types. Response: %s",
+		c.method, c.apiURL, contentType, strings.Join(c.contentTypes, ", "), excerpt,
+	)
+}
+
+// redactedError masks a secret in the message of the error it wraps
+type redactedError struct {
+	err    error
+	secret string
+}
+
+func (e *redactedError) Error() string {
+	return redactSecret(e.err.Error(), e.secret)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redact masks the service key in err: the errors of the HTTP client contain
+// the URL requested, which carries it with servicekey_query_param, and the
+// body of a failed response may reflect the servicekey header
+func (c *requestConfig) redact(err error) error {
+	if err == nil || c.serviceKey == "" {
+		return err
+	}
+	if _, ok := err.(*redactedError); ok {
+		return err
+	}
+	return &redactedError{err: err, secret: c.serviceKey}
+}
+
+// redactSecret replaces secret in s, including its URL-escaped form
+func redactSecret(s string, secret string) string {
+	if secret == "" {
+		return s
+	}
+	s = strings.ReplaceAll(s, url.QueryEscape(secret), redacted)
+	return strings.ReplaceAll(s, secret, redacted)
+}
+
+// decodedBody returns the body of a response, uncompressed when it is sent
+// gzipped. The transport only does so itself for the requests to which it
+// added Accept-Encoding, while gateways may compress large bodies regardless.
+// Closing it releases the decompressor; the body of the response is still
+// closed by the caller, once drained.
+func decodedBody(res *http.Response) (io.ReadCloser, error) {
+	if res.Uncompressed || !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return ioutil.NopCloser(res.Body), nil
+	}
+	return gzip.NewReader(res.Body)
+}
+
+// utf8BOM is rejected at the start of a body by strict JSON validators
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// compactBody strips the byte order mark and the trailing newline that an
+// encoder may add around a marshalled body; json.Marshal adds neither
+func compactBody(body []byte) []byte {
+	return bytes.TrimRight(bytes.TrimPrefix(body, utf8BOM), "\r\n")
+}
+
+// sensitiveBodyFields are the JSON fields whose values are masked when a
+// request body is logged
+var sensitiveBodyFields = map[string]bool{
+	"key":        true,
+	"servicekey": true,
+	"apikey":     true,
+	"accountkey": true,
+	"accesskey":  true,
+	"secretkey":  true,
+	"password":   true,
+}
+
+// debugBody formats a compact JSON body for the debug log. The result is
+// indented and its credentials are masked; the body sent is left untouched.
+func debugBody(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	indented, err := json.MarshalIndent(redactBody(v), "", "  ")
+	if err != nil {
+		return string(body)
+	}
+	return string(indented)
+}
+
+// redactBody masks the credentials of a decoded JSON value
+func redactBody(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(value))
+		for k, elem := range value {
+			switch {
+			case sensitiveBodyFields[strings.ToLower(k)] && elem != "":
+				masked[k] = redacted
+			case k == "headers":
+				// Webhook headers commonly carry authentication
+				headers, ok := elem.(map[string]interface{})
+				if !ok {
+					masked[k] = redactBody(elem)
+					break
+				}
+				maskedHeaders := make(map[string]interface{}, len(headers))
+				for name := range headers {
+					maskedHeaders[name] = redacted
+				}
+				masked[k] = maskedHeaders
+			default:
+				masked[k] = redactBody(elem)
+			}
+		}
+		// Slack webhook URLs embed their secret token
+		if value["integration"] == SLACK && value["url"] != nil {
+			masked["url"] = redacted
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, 0, len(value))
+		for _, elem := range value {
+			masked = append(masked, redactBody(elem))
+		}
+		return masked
+	}
+	return v
+}
+
+// isConnectionError reports whether err happened while connecting, before any
+// HTTP exchange, e.g. a refused connection or a failed DNS lookup
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isNotFoundErr reports whether err was caused by a 404 returned by the API
+func isNotFoundErr(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// isConflictErr reports whether err was caused by a 409 returned by the API,
+// e.g. when creating an entity which already exists
+func isConflictErr(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+// isPermissionErr reports whether err was caused by a service key which is not
+// allowed to call the endpoint
+func isPermissionErr(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	return apiErr.StatusCode == http.StatusForbidden && !isQuotaError(apiErr.StatusCode, apiErr.Body)
+}
+
+// quotaMessages identify a 403 caused by the plan of the account rather than by
+// the permissions of the service key
+var quotaMessages = []string{"quota", "billing", "payment", "plan limit", "upgrade"}
+
+// isQuotaError reports whether a response was rejected because of billing or quota
+func isQuotaError(statusCode int, body []byte) bool {
+	if statusCode == http.StatusPaymentRequired {
+		return true
+	}
+	if statusCode != http.StatusForbidden {
+		return false
+	}
+	msg := strings.ToLower(string(body))
+	for _, q := range quotaMessages {
+		if strings.Contains(msg, q) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package logdna
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The phases of an HTTP exchange, as reported by timeout errors
+const (
+	phaseConnect = "connect"
+	phaseTLS     = "TLS handshake"
+	phaseHeaders = "response headers"
+	phaseBody    = "response body"
+)
+
+// phaseTimeouts bound each phase of a request; zero values keep the defaults
+// of the transport. request bounds the whole exchange, body included.
+type phaseTimeouts struct {
+	connect time.Duration
+	tls     time.Duration
+	headers time.Duration
+	request time.Duration
+}
+
+const (
+	defaultDialTimeout    = 30 * time.Second
+	defaultRequestTimeout = 15 * time.Second
+)
+
+func (t phaseTimeouts) dialTimeout() time.Duration {
+	if t.connect > 0 {
+		return t.connect
+	}
+	return defaultDialTimeout
+}
+
+func (t phaseTimeouts) requestTimeout() time.Duration {
+	if t.request > 0 {
+		return t.request
+	}
+	return defaultRequestTimeout
+}
+
+// httpTimeoutKeys are the keys accepted by the http_timeouts provider setting
+var httpTimeoutKeys = []string{"connect", "headers", "request", "tls"}
+
+// parsePhaseTimeouts converts the http_timeouts provider setting, rejecting
+// unknown phases and durations which are not positive
+func parsePhaseTimeouts(settings map[string]interface{}) (phaseTimeouts, error) {
+	timeouts := phaseTimeouts{}
+	fields := map[string]*time.Duration{
+		"connect": &timeouts.connect,
+		"headers": &timeouts.headers,
+		"request": &timeouts.request,
+		"tls":     &timeouts.tls,
+	}
+	for key, raw := range settings {
+		field, ok := fields[key]
+		if !ok {
+			return timeouts, fmt.Errorf("unknown http_timeouts phase %q, expected one of: %s", key, strings.Join(httpTimeoutKeys, ", "))
+		}
+		value, _ := raw.(string)
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return timeouts, fmt.Errorf("http_timeouts.%s must be a positive duration like \"10s\", got: %q", key, value)
+		}
+		*field = d
+	}
+	return timeouts, nil
+}
+
+// phaseTracker records the phase a request is in, so that a timeout can be
+// attributed to the phase which stalled. The hooks of httptrace may be called
+// from other goroutines than the one of the request.
+type phaseTracker struct {
+	mu    sync.Mutex
+	phase string
+	since time.Time
+}
+
+func newPhaseTracker() *phaseTracker {
+	return &phaseTracker{phase: phaseConnect, since: time.Now()}
+}
+
+func (p *phaseTracker) enter(phase string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase, p.since = phase, time.Now()
+}
+
+// current returns the phase in progress and how long it has lasted
+func (p *phaseTracker) current() (string, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.phase, time.Since(p.since)
+}
+
+// trace moves the tracker along the phases of the request. The connect phase
+// lasts until a connection is obtained, except for the TLS handshake of new
+// connections.
+func (p *phaseTracker) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn:           func(string) { p.enter(phaseConnect) },
+		TLSHandshakeStart: func() { p.enter(phaseTLS) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			p.enter(phaseConnect)
+		},
+		// Sending the request is attributed to the wait for its response
+		GotConn:              func(httptrace.GotConnInfo) { p.enter(phaseHeaders) },
+		GotFirstResponseByte: func() { p.enter(phaseBody) },
+	}
+}
+
+// withPhase attributes a timeout error to the phase which stalled; the other
+// errors are returned unchanged
+func (p *phaseTracker) withPhase(err error) error {
+	if !isTimeoutErr(err) {
+		return err
+	}
+	phase, elapsed := p.current()
+	return fmt.Errorf("timed out in the %s phase after %s: %w", phase, elapsed.Round(time.Millisecond), err)
+}
+
+// isTimeoutErr reports whether err was caused by a timeout of the client, of
+// the transport or of the deadline of the request
+func isTimeoutErr(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,95 @@
+package logdna
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestPhases_TimeoutAttribution(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("Reports a server slow to send its headers", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(300 * time.Millisecond)
+			fmt.Fprint(w, `{}`)
+		}))
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 100 * time.Millisecond}}
+
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "timed out in the response headers phase after", "The phase is reported")
+		assert.True(isTimeoutErr(errors.Unwrap(err)), "The timeout is still unwrappable")
+	})
+
+	t.Run("Reports a server slow to send its body", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"name": `)
+			w.(http.Flusher).Flush()
+			time.Sleep(300 * time.Millisecond)
+			fmt.Fprint(w, `"test"}`)
+		}))
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 100 * time.Millisecond}}
+
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "timed out in the response body phase after", "The phase is reported")
+	})
+
+	t.Run("Honors the headers timeout of http_timeouts", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(300 * time.Millisecond)
+			fmt.Fprint(w, `{}`)
+		}))
+		defer ts.Close()
+		c := newHTTPClient(httpClientOptions{timeouts: phaseTimeouts{headers: 100 * time.Millisecond}})
+		pc := providerConfig{baseURL: ts.URL, httpClient: c}
+
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "timed out in the response headers phase after", "The phase is reported")
+	})
+
+	t.Run("Leaves other errors unchanged", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		ts.Close()
+
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.NotContains(err.Error(), "timed out", "A refused connection is no timeout")
+	})
+}
+
+func TestRequestPhases_parsePhaseTimeouts(t *testing.T) {
+	assert := assert.New(t)
+
+	timeouts, err := parsePhaseTimeouts(map[string]interface{}{
+		"connect": "5s",
+		"tls":     "3s",
+		"headers": "1m",
+		"request": "90s",
+	})
+	assert.Nil(err, "No errors")
+	assert.Equal(phaseTimeouts{connect: 5 * time.Second, tls: 3 * time.Second, headers: time.Minute, request: 90 * time.Second}, timeouts)
+
+	timeouts, err = parsePhaseTimeouts(map[string]interface{}{})
+	assert.Nil(err, "No errors")
+	assert.Equal(defaultDialTimeout, timeouts.dialTimeout(), "The defaults are kept")
+	assert.Equal(defaultRequestTimeout, timeouts.requestTimeout(), "The defaults are kept")
+
+	_, err = parsePhaseTimeouts(map[string]interface{}{"body": "5s"})
+	assert.EqualError(err, `unknown http_timeouts phase "body", expected one of: connect, headers, request, tls`)
+
+	for _, value := range []string{"5", "-1s", "0s", "soon"} {
+		_, err = parsePhaseTimeouts(map[string]interface{}{"headers": value})
+		assert.EqualError(err, fmt.Sprintf(`http_timeouts.headers must be a positive duration like "10s", got: %q`, value))
+	}
+}
@@ -0,0 +1,91 @@
+package logdna
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// requestSummary describes the latency of the requests made so far
+type requestSummary struct {
+	Count int
+	P50   time.Duration
+	P90   time.Duration
+	Max   time.Duration
+}
+
+func (s requestSummary) String() string {
+	return fmt.Sprintf("%d requests, p50 %s, p90 %s, max %s", s.Count, s.P50, s.P90, s.Max)
+}
+
+// summaryLogInterval is the least time between two summaries logged
+const summaryLogInterval = 10 * time.Second
+
+// requestDurations collects the duration of every request of the run when
+// log_request_summary is set. Requests run in parallel, hence the lock.
+type requestDurations struct {
+	mu        sync.Mutex
+	durations []time.Duration
+	// interval throttles the summaries logged, see afterRequest
+	interval time.Duration
+	logged   time.Time
+	flush    *time.Timer
+}
+
+// summary summarizes the durations recorded so far
+func (r *requestDurations) summary() requestSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return summarizeDurations(r.durations)
+}
+
+// afterRequest records the duration of a request and logs the summary at most
+// once per interval. The provider is not told when the apply ends, so the
+// requests made since the last summary are logged by a flush at the end of the
+// interval, which covers the whole run once the last request is made.
+func (r *requestDurations) afterRequest(req *http.Request, res *http.Response, body []byte, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.durations = append(r.durations, elapsed)
+	if r.flush != nil {
+		return
+	}
+	if wait := r.interval - time.Since(r.logged); wait > 0 {
+		r.flush = time.AfterFunc(wait, r.logSummary)
+		return
+	}
+	r.logged = time.Now()
+	log.Printf("[DEBUG] Request summary: %s", summarizeDurations(r.durations))
+}
+
+// logSummary logs the summary of the requests recorded so far
+func (r *requestDurations) logSummary() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flush = nil
+	r.logged = time.Now()
+	log.Printf("[DEBUG] Request summary: %s", summarizeDurations(r.durations))
+}
+
+// summarizeDurations uses the nearest-rank percentiles of the durations
+func summarizeDurations(durations []time.Duration) requestSummary {
+	if len(durations) == 0 {
+		return requestSummary{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := func(percentile int) time.Duration {
+		i := (percentile*len(sorted)+99)/100 - 1
+		return sorted[i]
+	}
+	return requestSummary{
+		Count: len(sorted),
+		P50:   rank(50),
+		P90:   rank(90),
+		Max:   sorted[len(sorted)-1],
+	}
+}
@@ -0,0 +1,110 @@
+package logdna
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestSummary_summary(t *testing.T) {
+	assert := assert.New(t)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	r := &requestDurations{interval: time.Hour}
+	r.afterRequest(nil, nil, nil, 7*time.Millisecond)
+	assert.Equal(requestSummary{Count: 1, P50: 7 * time.Millisecond, P90: 7 * time.Millisecond, Max: 7 * time.Millisecond}, r.summary(), "A single request")
+
+	r = &requestDurations{interval: time.Hour}
+	for _, ms := range []int{10, 3, 8, 1, 5, 9, 2, 7, 4, 6} {
+		r.afterRequest(nil, nil, nil, time.Duration(ms)*time.Millisecond)
+	}
+	summary := r.summary()
+	assert.Equal(10, summary.Count, "Every request is counted")
+	assert.Equal(5*time.Millisecond, summary.P50, "p50")
+	assert.Equal(9*time.Millisecond, summary.P90, "p90")
+	assert.Equal(10*time.Millisecond, summary.Max, "max")
+	assert.Equal("10 requests, p50 5ms, p90 9ms, max 10ms", summary.String(), "Formatted for the log")
+	r.flush.Stop()
+
+	assert.Equal(requestSummary{}, summarizeDurations(nil), "Nothing recorded")
+}
+
+func TestRequestSummary_Throttled(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	log.SetOutput(&lockedWriter{mu: &mu, w: &buf})
+	defer log.SetOutput(os.Stderr)
+	logged := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return bytes.Count(buf.Bytes(), []byte("[DEBUG] Request summary: "))
+	}
+
+	r := &requestDurations{interval: 50 * time.Millisecond}
+	for i := 0; i < 100; i++ {
+		r.afterRequest(nil, nil, nil, time.Millisecond)
+	}
+	assert.Equal(1, logged(), "The first request is summarized, the next ones wait for the interval")
+
+	assert.Eventually(func() bool { return logged() == 2 }, time.Second, 10*time.Millisecond, "The requests since are flushed at the end of the interval")
+	mu.Lock()
+	assert.Contains(buf.String(), "Request summary: 100 requests, p50 ", "The last summary covers every request")
+	mu.Unlock()
+}
+
+// lockedWriter serializes the writes of the log and its reads by the test
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+func TestRequestSummary_LogRequestSummary(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	for _, enabled := range []bool{false, true} {
+		buf.Reset()
+		d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+			"servicekey":          "key",
+			"url":                 ts.URL,
+			"log_request_summary": enabled,
+		})
+		m, err := providerConfigure(d)
+		assert.Nil(err, "No errors")
+		pc := m.(*providerConfig)
+
+		for i := 0; i < 2; i++ {
+			_, err = newRequestConfig(pc, "GET", "/v1/config/view", nil).MakeRequest()
+			assert.Nil(err, "No errors")
+		}
+		logged := bytes.Count(buf.Bytes(), []byte("[DEBUG] Request summary: "))
+		if enabled {
+			assert.Equal(1, logged, "A summary is logged at most once per interval")
+		} else {
+			assert.Equal(0, logged, "Nothing is logged by default")
+		}
+	}
+	assert.Contains(buf.String(), "Request summary: 1 requests, p50 ", "The first request is summarized")
+}
@@ -1,17 +1,24 @@
 package logdna
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,9 +28,18 @@ func (fc *badClient) Do(*http.Request) (*http.Response, error) {
 	return nil, errors.New("FAKE ERROR calling httpClient.Do")
 }
 
-func setHTTPRequest(customReq httpRequest) func(*requestConfig) {
+// echoClient fails with an error which dumps the request headers
+type echoClient struct{}
+
+func (fc *echoClient) Do(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("FAKE ERROR calling httpClient.Do with headers %v", req.Header)
+}
+
+func setHTTPRequest(customReq func(string, string, io.Reader) (*http.Request, error)) func(*requestConfig) {
 	return func(req *requestConfig) {
-		req.httpRequest = customReq
+		req.httpRequest = func(_ context.Context, method, url string, body io.Reader) (*http.Request, error) {
+			return customReq(method, url, body)
+		}
 	}
 }
 
@@ -39,6 +55,14 @@ func setJSONMarshal(customMarshaller jsonMarshal) func(*requestConfig) {
 	}
 }
 
+func setRetryPolicy(retryMax int, waitMin, waitMax time.Duration) func(*requestConfig) {
+	return func(req *requestConfig) {
+		req.RetryMax = retryMax
+		req.RetryWaitMin = waitMin
+		req.RetryWaitMax = waitMax
+	}
+}
+
 func TestRequest_MakeRequest(t *testing.T) {
 	assert := assert.New(t)
 	pc := providerConfig{serviceKey: "abc123", httpClient: &http.Client{Timeout: 15 * time.Second}}
@@ -183,7 +207,7 @@ func TestRequest_MakeRequest(t *testing.T) {
 		)
 	})
 
-	t.Run("Throws non-200 errors returned by the server", func(t *testing.T) {
+	t.Run("Throws non-2xx errors returned by the server", func(t *testing.T) {
 		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(400)
 		}))
@@ -207,6 +231,40 @@ func TestRequest_MakeRequest(t *testing.T) {
 		)
 	})
 
+	t.Run("Reports quota and billing errors distinctly", func(t *testing.T) {
+		cases := []struct {
+			status int
+			body   string
+			quota  bool
+		}{
+			{402, `{"error":"Account is over its monthly quota"}`, true},
+			{402, ``, true},
+			{403, `{"error":"Billing is past due"}`, true},
+			{403, `{"error":"Invalid service key"}`, false},
+			{500, `{"error":"quota service unavailable"}`, false},
+		}
+		for _, tc := range cases {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "0") // Retried without waiting
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			pc.baseURL = ts.URL
+
+			_, err := newRequestConfig(&pc, "POST", "/v1/config/view", nil).MakeRequest()
+			assert.Error(err, "Expected error")
+			assert.Contains(err.Error(), fmt.Sprintf("status %d NOT OK!", tc.status), "Status is kept")
+			assert.Equal(
+				tc.quota,
+				strings.Contains(err.Error(), "over quota or has a billing issue; upgrade the plan"),
+				"Quota guidance for %d %s",
+				tc.status,
+				tc.body,
+			)
+			ts.Close()
+		}
+	})
+
 	t.Run("Handles errors when creating a new HTTP request", func(t *testing.T) {
 		const ERROR = "FAKE ERROR for body reader"
 		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -235,3 +293,993 @@ func TestRequest_MakeRequest(t *testing.T) {
 		)
 	})
 }
+
+// Run with `go test -race` to detect unsynchronized state shared through providerConfig
+func TestRequest_MakeRequestConcurrently(t *testing.T) {
+	assert := assert.New(t)
+	const workers = 50
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		postedBody, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(postedBody)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{serviceKey: "abc123", baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("view-%d", i)
+			body, err := newRequestConfig(&pc, "POST", "/v1/config/view", viewRequest{Name: name}).MakeRequest()
+			if err == nil && string(body) != fmt.Sprintf(`{"name":%q}`, name) {
+				err = fmt.Errorf("unexpected body for %s: %s", name, body)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.Nil(err, "No errors")
+	}
+}
+
+func TestRequest_RetryMessages(t *testing.T) {
+	assert := assert.New(t)
+	setRetryWait := func(req *requestConfig) { req.retryWait = time.Millisecond }
+
+	t.Run("Retries a 200 with a retryable error message until it succeeds", func(t *testing.T) {
+		calls := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				fmt.Fprint(w, `{"error":"Temporarily Unavailable"}`)
+				return
+			}
+			fmt.Fprint(w, `{"id":"abc"}`)
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{
+			baseURL:       ts.URL,
+			httpClient:    &http.Client{Timeout: 15 * time.Second},
+			retryMessages: []string{"temporarily unavailable"},
+		}
+		body, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, setRetryWait).MakeRequest()
+		assert.Nil(err, "No errors")
+		assert.Equal(`{"id":"abc"}`, string(body), "The successful body is returned")
+		assert.Equal(2, calls, "The request was retried once")
+	})
+
+	t.Run("Returns the last body once the retries are exhausted", func(t *testing.T) {
+		calls := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			fmt.Fprint(w, `{"error":"temporarily unavailable"}`)
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{
+			baseURL:       ts.URL,
+			httpClient:    &http.Client{Timeout: 15 * time.Second},
+			retryMessages: []string{"temporarily unavailable"},
+		}
+		body, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, setRetryWait).MakeRequest()
+		assert.Nil(err, "No errors")
+		assert.Equal(`{"error":"temporarily unavailable"}`, string(body), "The last body is returned")
+		assert.Equal(maxMessageRetries+1, calls, "The request was retried up to the limit")
+	})
+
+	t.Run("Does not retry without configured messages", func(t *testing.T) {
+		calls := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			fmt.Fprint(w, `{"error":"temporarily unavailable"}`)
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, setRetryWait).MakeRequest()
+		assert.Nil(err, "No errors")
+		assert.Equal(1, calls, "The request was sent once")
+	})
+
+	t.Run("Only matches the error of the body", func(t *testing.T) {
+		calls := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			fmt.Fprint(w, `{"viewID":"abc","query":"temporarily unavailable"}`)
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{
+			baseURL:       ts.URL,
+			httpClient:    &http.Client{Timeout: 15 * time.Second},
+			retryMessages: []string{"temporarily unavailable"},
+		}
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, setRetryWait).MakeRequest()
+		assert.Nil(err, "No errors")
+		assert.Equal(1, calls, "A view whose query contains the message is not retried")
+	})
+}
+
+func TestRequest_Hooks(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("audit-123", r.Header.Get("X-Audit-Id"), "The before hook can mutate the request")
+		w.WriteHeader(201)
+		fmt.Fprint(w, `{"created":true}`)
+	}))
+	defer ts.Close()
+
+	var calls []string
+	pc := providerConfig{
+		baseURL:    ts.URL,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		beforeRequest: func(req *http.Request) {
+			calls = append(calls, "before")
+			assert.Equal("PUT", req.Method, "Method is passed")
+			assert.Equal(ts.URL+"/v1/config/view/abc", req.URL.String(), "URL is passed")
+			req.Header.Set("X-Audit-Id", "audit-123")
+		},
+		afterRequest: func(req *http.Request, res *http.Response, body []byte, elapsed time.Duration) {
+			calls = append(calls, "after")
+			assert.Equal("PUT", req.Method, "Method is passed")
+			assert.Equal(201, res.StatusCode, "Status is passed")
+			assert.Equal(`{"created":true}`, string(body), "Body is passed")
+			assert.True(elapsed > 0, "Elapsed time is measured")
+		},
+	}
+
+	body, err := newRequestConfig(&pc, "PUT", "/v1/config/view/abc", nil).MakeRequest()
+	assert.Nil(err, "2xx responses are successful")
+	assert.Equal(`{"created":true}`, string(body), "Body is returned")
+	assert.Equal([]string{"before", "after"}, calls, "Both hooks fired in order")
+
+	t.Run("Calls the after hook without a response when the request fails", func(t *testing.T) {
+		calls = nil
+		pc.afterRequest = func(req *http.Request, res *http.Response, body []byte, elapsed time.Duration) {
+			calls = append(calls, "after")
+			assert.Nil(res, "No response")
+			assert.Nil(body, "No body")
+		}
+		_, err := newRequestConfig(&pc, "PUT", "/v1/config/view/abc", nil, func(req *requestConfig) {
+			req.httpClient = &badClient{}
+		}).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.Equal([]string{"before", "after"}, calls, "Both hooks fired in order")
+	})
+
+	t.Run("Works without hooks", func(t *testing.T) {
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		_, err := newRequestConfig(&pc, "GET", "/", nil, func(req *requestConfig) {
+			req.httpClient = &badClient{}
+		}).MakeRequest()
+		assert.Error(err, "Expected error")
+	})
+}
+
+func TestRequest_SuccessStatuses(t *testing.T) {
+	assert := assert.New(t)
+	status := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	for _, status = range []int{200, 201, 202, 204} {
+		_, err := newRequestConfig(&pc, "DELETE", "/v1/config/view/abc", nil).MakeRequest()
+		assert.Nil(err, "%d is successful", status)
+	}
+	for _, status = range []int{304, 400, 404} {
+		_, err := newRequestConfig(&pc, "DELETE", "/v1/config/view/abc", nil).MakeRequest()
+		var apiErr *APIError
+		assert.True(errors.As(err, &apiErr), "%d is an APIError", status)
+	}
+}
+func TestRequest_Deadline(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"error":"temporarily unavailable"}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{
+		baseURL:       ts.URL,
+		httpClient:    &http.Client{Timeout: 15 * time.Second},
+		retryMessages: []string{"temporarily unavailable"},
+	}
+
+	t.Run("Bounds the total time spent retrying", func(t *testing.T) {
+		calls = 0
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, func(req *requestConfig) {
+			req.retryWait = 100 * time.Millisecond
+		}).MakeRequestWithContext(ctx)
+		elapsed := time.Since(start)
+
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "deadline exceeded after 3 attempts", "Error names the deadline")
+		assert.Equal(3, calls, "Retries stop before the deadline")
+		assert.True(elapsed < 300*time.Millisecond, "Total time is bounded, took %s", elapsed)
+	})
+
+	t.Run("Stops retrying failed statuses before the deadline", func(t *testing.T) {
+		unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(503)
+		}))
+		defer unavailable.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		pc := providerConfig{baseURL: unavailable.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		start := time.Now()
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequestWithContext(ctx)
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "deadline exceeded after 1 attempts", "Error names the deadline")
+		assert.Contains(err.Error(), "status 503", "The last error is kept")
+		var apiErr *APIError
+		assert.True(errors.As(err, &apiErr), "The error is still an APIError")
+		assert.True(time.Since(start) < 300*time.Millisecond, "The retry was not attempted")
+	})
+
+	t.Run("Aborts a request outliving the deadline", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer slow.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		pc := providerConfig{baseURL: slow.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		start := time.Now()
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequestWithContext(ctx)
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "deadline exceeded", "Error names the deadline")
+		assert.True(time.Since(start) < time.Second, "Request was aborted")
+	})
+
+	t.Run("Has no deadline without one on the context", func(t *testing.T) {
+		calls = 0
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, func(req *requestConfig) {
+			req.retryWait = time.Millisecond
+		}).MakeRequestWithContext(context.Background())
+		assert.Nil(err, "No errors")
+		assert.Equal(maxMessageRetries+1, calls, "Every retry was attempted")
+	})
+}
+
+func TestRequest_DebugBody(t *testing.T) {
+	assert := assert.New(t)
+	body := map[string]interface{}{
+		"name": "test",
+		"channels": []interface{}{
+			map[string]interface{}{"integration": SLACK, "url": "https://hooks.slack.com/secret"},
+			map[string]interface{}{"integration": PAGERDUTY, "key": "pd-key"},
+			map[string]interface{}{"integration": WEBHOOK, "url": "https://example.com", "headers": map[string]string{"Authorization": "token"}},
+		},
+	}
+
+	var sent []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		sent, err = ioutil.ReadAll(r.Body)
+		assert.Nil(err, "No errors")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	req := newRequestConfig(
+		&providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}},
+		"POST",
+		"/",
+		body,
+		func(req *requestConfig) {
+			req.logBody = true
+		},
+	)
+	_, err := req.MakeRequest()
+	assert.Nil(err, "No errors")
+
+	compact, _ := json.Marshal(body)
+	assert.Equal(string(compact), string(sent), "The sent body is compact")
+
+	output := logged.String()
+	assert.Contains(output, "request body:\n{\n  \"channels\": [", "The logged body is indented")
+	assert.Contains(output, `"key": "***REDACTED***"`, "The PagerDuty key is redacted")
+	assert.Contains(output, `"Authorization": "***REDACTED***"`, "The headers are redacted")
+	assert.Contains(output, `"url": "https://example.com"`, "Webhook URLs are kept")
+	assert.NotContains(output, "pd-key", "No secret is logged")
+	assert.NotContains(output, "hooks.slack.com", "No Slack URL is logged")
+	assert.NotContains(output, "token", "No header value is logged")
+
+	t.Run("Is not logged by default", func(t *testing.T) {
+		logged.Reset()
+		req.logBody = false
+		_, err := req.MakeRequest()
+		assert.Nil(err, "No errors")
+		assert.NotContains(logged.String(), "request body", "The body is not logged")
+	})
+}
+
+func TestRequest_Do(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("Decodes into a struct", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"viewID": "abc", "name": "test", "query": "level:error", "presetIds": ["p1", 2]}`)
+		}))
+		defer ts.Close()
+
+		req := newRequestConfig(&providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}, "GET", "/", nil)
+		view, err := Do[viewResponse](context.Background(), req)
+		assert.Nil(err, "No errors")
+		assert.Equal(flexID("abc"), view.ViewID, "ViewID is decoded")
+		assert.Equal("test", view.Name, "Name is decoded")
+		assert.Equal([]string{"p1", "2"}, view.presetIDs(), "Preset IDs are decoded")
+	})
+
+	t.Run("Decodes into a slice", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"id": "a", "title": "first", "active": true}, {"id": "b", "title": "second", "active": "false"}]`)
+		}))
+		defer ts.Close()
+
+		req := newRequestConfig(&providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}, "GET", "/", nil)
+		rules, err := Do[[]exclusionRule](context.Background(), req)
+		assert.Nil(err, "No errors")
+		assert.Len(rules, 2, "Every element is decoded")
+		assert.Equal(flexID("b"), rules[1].ID, "ID is decoded")
+		assert.False(bool(rules[1].Active), "Active is decoded")
+	})
+
+	t.Run("Returns the request error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "0") // Retried without waiting
+			w.WriteHeader(500)
+		}))
+		defer ts.Close()
+
+		req := newRequestConfig(&providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}, "GET", "/", nil)
+		_, err := Do[viewResponse](context.Background(), req)
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "status 500 NOT OK!", "The status is reported")
+	})
+
+	t.Run("Returns a decode error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"name": 1}`)
+		}))
+		defer ts.Close()
+
+		req := newRequestConfig(&providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}, "GET", "/", nil)
+		_, err := Do[viewResponse](context.Background(), req)
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "cannot decode the response", "The decode error is reported")
+	})
+}
+
+func TestRequest_CompactBody(t *testing.T) {
+	assert := assert.New(t)
+
+	var sent []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		sent, err = ioutil.ReadAll(r.Body)
+		assert.Nil(err, "No errors")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	body := map[string]string{"name": "test"}
+
+	_, err := newRequestConfig(&pc, "POST", "/", body).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Equal(`{"name":"test"}`, string(sent), "The body has no trailing newline")
+
+	t.Run("Strips what an encoder adds around the body", func(t *testing.T) {
+		_, err := newRequestConfig(&pc, "POST", "/", body, setJSONMarshal(func(v interface{}) ([]byte, error) {
+			var buf bytes.Buffer
+			buf.Write(utf8BOM)
+			err := json.NewEncoder(&buf).Encode(v)
+			return buf.Bytes(), err
+		})).MakeRequest()
+		assert.Nil(err, "No errors")
+		assert.Equal(`{"name":"test"}`, string(sent), "The BOM and the trailing newline are stripped")
+		assert.False(bytes.HasSuffix(sent, []byte("\n")), "No trailing newline")
+	})
+}
+
+func TestRequest_AcceptCharset(t *testing.T) {
+	assert := assert.New(t)
+
+	var headers http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	_, err := newRequestConfig(&pc, "POST", "/", map[string]string{"name": "test"}).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Equal("application/json", headers.Get("Content-Type"), "No charset by default")
+	assert.Empty(headers.Values("Accept-Charset"), "No Accept-Charset by default")
+
+	pc.acceptCharset = "utf-8"
+	_, err = newRequestConfig(&pc, "POST", "/", map[string]string{"name": "test"}).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Equal("application/json; charset=utf-8", headers.Get("Content-Type"), "The charset is declared")
+	assert.Equal("utf-8", headers.Get("Accept-Charset"), "The charset is negotiated")
+}
+
+func TestRequest_StrictWarnings(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "test", "warnings": ["query is deprecated", {"code": "W1"}]}`)
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	body, err := newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+	assert.Nil(err, "Warnings pass by default")
+	assert.Contains(string(body), `"name": "test"`, "The body is returned")
+
+	pc.strict = true
+	body, err = newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+	assert.Nil(body, "No body")
+	assert.EqualError(
+		err,
+		fmt.Sprintf(`GET %s/, the API returned warnings in strict mode: query is deprecated; {"code": "W1"}`, ts.URL),
+		"Warnings fail under strict mode",
+	)
+
+	t.Run("Passes responses without warnings under strict mode", func(t *testing.T) {
+		for _, response := range []string{`{"name": "test"}`, `{"warnings": []}`, `["a", "b"]`} {
+			response := response
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, response)
+			}))
+			pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}, strict: true}
+			_, err := newRequestConfig(&pc, "GET", "/", nil).MakeRequest()
+			assert.Nil(err, "No errors for %s", response)
+			ts.Close()
+		}
+	})
+}
+
+func TestRequest_TraceConnections(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	pc := providerConfig{
+		baseURL:          ts.URL,
+		httpClient:       &http.Client{Timeout: 15 * time.Second, Transport: &http.Transport{}},
+		traceConnections: true,
+	}
+	_, err := newRequestConfig(&pc, "GET", "/first", nil).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Contains(logged.String(), fmt.Sprintf("GET %s/first, dialed new connection", ts.URL), "The first request dials")
+
+	logged.Reset()
+	_, err = newRequestConfig(&pc, "GET", "/second", nil).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Contains(logged.String(), fmt.Sprintf("GET %s/second, reused connection", ts.URL), "The second request reuses the connection")
+
+	logged.Reset()
+	pc.traceConnections = false
+	_, err = newRequestConfig(&pc, "GET", "/third", nil).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.NotContains(logged.String(), "connection", "Connections are not traced by default")
+}
+
+func TestRequest_FallbackHost(t *testing.T) {
+	assert := assert.New(t)
+
+	var fallbackHeaders http.Header
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackHeaders = r.Header.Clone()
+		assert.Equal("/v1/config/view", r.URL.Path, "The path is kept")
+		fmt.Fprint(w, `{"viewID":"abc"}`)
+	}))
+	defer fallback.Close()
+
+	// The address of a closed server refuses connections
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachable.Close()
+
+	pc := providerConfig{
+		serviceKey:   "abc123",
+		baseURL:      unreachable.URL,
+		fallbackHost: fallback.URL,
+		httpClient:   &http.Client{Timeout: 15 * time.Second},
+	}
+	body, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Equal(`{"viewID":"abc"}`, string(body), "The fallback host answered")
+	assert.Equal("abc123", fallbackHeaders.Get("servicekey"), "The service key is sent to the fallback host")
+
+	t.Run("Does not fall back on HTTP errors", func(t *testing.T) {
+		fallbackHeaders = nil
+		primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "0") // Retried without waiting
+			w.WriteHeader(500)
+		}))
+		defer primary.Close()
+
+		pc.baseURL = primary.URL
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "status 500 NOT OK!", "The error of the primary host is returned")
+		assert.Nil(fallbackHeaders, "The fallback host was not requested")
+	})
+
+	t.Run("Fails without a fallback host", func(t *testing.T) {
+		pc.baseURL, pc.fallbackHost = unreachable.URL, ""
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.True(isConnectionError(err), "The connection failed")
+	})
+}
+
+func TestRequest_ServiceKeyQueryParam(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	var query map[string][]string
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		assert.Equal("secret-key", r.Header.Get("servicekey"), "The header is still sent")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer fallback.Close()
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachable.Close()
+
+	pc := providerConfig{
+		serviceKey:      "secret-key",
+		serviceKeyParam: "api_key",
+		baseURL:         unreachable.URL,
+		fallbackHost:    fallback.URL,
+		httpClient:      &http.Client{Timeout: 15 * time.Second},
+	}
+	_, err := newRequestConfig(&pc, "GET", "/v1/config/view?page=2", nil).MakeRequest()
+	assert.Nil(err, "No errors")
+	assert.Equal([]string{"secret-key"}, query["api_key"], "The key is placed in the query")
+	assert.Equal([]string{"2"}, query["page"], "The query of the URI is kept")
+
+	assert.Contains(buf.String(), "is unreachable, falling back to", "The failure is logged")
+	assert.NotContains(buf.String(), "secret-key", "The key is redacted from the logs")
+
+	pc.fallbackHost = ""
+	_, err = newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+	assert.Error(err, "Expected error")
+	assert.NotContains(err.Error(), "secret-key", "The key is redacted from the error")
+	assert.Contains(err.Error(), "api_key="+redacted, "The parameter is still named")
+	assert.True(isConnectionError(err), "The cause is kept")
+}
+
+func TestRequest_StatusRetries(t *testing.T) {
+	assert := assert.New(t)
+	fast := setRetryPolicy(3, time.Millisecond, 4*time.Millisecond)
+
+	serve := func(statuses ...int) (*httptest.Server, *int) {
+		calls := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls <= len(statuses) {
+				w.WriteHeader(statuses[calls-1])
+				fmt.Fprintf(w, `{"error":"attempt %d"}`, calls)
+				return
+			}
+			fmt.Fprint(w, `{"ok":true}`)
+		}))
+		return ts, &calls
+	}
+
+	t.Run("Retries 429 and 5xx responses", func(t *testing.T) {
+		ts, calls := serve(429, 503, 500)
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		body, err := newRequestConfig(&pc, "POST", "/v1/config/view", nil, fast).MakeRequest()
+		assert.Nil(err, "No errors")
+		assert.Equal(`{"ok":true}`, string(body), "The last response is returned")
+		assert.Equal(4, *calls, "Retried until success")
+	})
+
+	t.Run("Returns the last error once the retries are exhausted", func(t *testing.T) {
+		ts, calls := serve(502, 502, 502, 503)
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, fast).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.Equal(
+			fmt.Sprintf(`GET %s/v1/config/view, status 503 NOT OK! {"error":"attempt 4"}`, ts.URL),
+			err.Error(),
+			"The error is unchanged",
+		)
+		assert.Equal(4, *calls, "The first attempt and 3 retries")
+	})
+
+	t.Run("Does not retry other errors", func(t *testing.T) {
+		ts, calls := serve(400, 404)
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, fast).MakeRequest()
+		assert.Contains(err.Error(), "status 400 NOT OK!", "The error is returned")
+		assert.Equal(1, *calls, "Not retried")
+	})
+
+	t.Run("Stops before the deadline", func(t *testing.T) {
+		ts, calls := serve(500, 500)
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, setRetryPolicy(3, time.Minute, time.Minute), func(req *requestConfig) {
+			req.deadline = time.Now().Add(time.Second)
+		}).MakeRequest()
+		assert.Contains(err.Error(), "status 500 NOT OK!", "The error is returned")
+		assert.Equal(1, *calls, "Not retried past the deadline")
+	})
+
+	t.Run("Backs off exponentially unless Retry-After is sent", func(t *testing.T) {
+		req := newRequestConfig(&providerConfig{}, "GET", "/", nil, setRetryPolicy(4, time.Second, 5*time.Second))
+		err := &APIError{StatusCode: 503, Header: http.Header{}}
+		for retries, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+			wait, ok := req.statusRetryWait(err, retries)
+			assert.True(ok, "Retried")
+			assert.Equal(expected, wait, "Wait after %d retries", retries)
+		}
+
+		err.Header.Set("Retry-After", "3")
+		wait, _ := req.statusRetryWait(err, 0)
+		assert.Equal(3*time.Second, wait, "Retry-After in seconds is honored")
+
+		err.Header.Set("Retry-After", "86400")
+		wait, _ = req.statusRetryWait(err, 0)
+		assert.Equal(5*time.Second, wait, "Retry-After is capped to the maximum wait")
+
+		now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+		wait, ok := retryAfter(http.Header{"Retry-After": {"Wed, 01 Jun 2022 12:00:30 GMT"}}, now)
+		assert.True(ok, "An HTTP date is parsed")
+		assert.Equal(30*time.Second, wait, "Retry-After as a date is honored")
+		_, ok = retryAfter(http.Header{"Retry-After": {"soon"}}, now)
+		assert.False(ok, "Invalid values are ignored")
+	})
+}
+
+func TestRequest_APIError(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(404)
+		fmt.Fprint(w, `{"error":"Nothing found"}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	_, err := newRequestConfig(&pc, "GET", "/v1/config/view/abc", nil).MakeRequest()
+
+	var apiErr *APIError
+	assert.True(errors.As(err, &apiErr), "The error is an APIError")
+	assert.Equal(404, apiErr.StatusCode, "StatusCode")
+	assert.Equal("404 Not Found", apiErr.Status, "Status")
+	assert.Equal(`{"error":"Nothing found"}`, string(apiErr.Body), "Body")
+	assert.Equal("req-1", apiErr.Header.Get("X-Request-Id"), "Header")
+	assert.Equal(
+		fmt.Sprintf(`GET %s/v1/config/view/abc, status 404 NOT OK! {"error":"Nothing found"}`, ts.URL),
+		err.Error(),
+		"The message is unchanged",
+	)
+	assert.True(isNotFoundErr(fmt.Errorf("reading: %w", err)), "Found through wrapping")
+	assert.False(isNotFoundErr(errors.New("status 404 NOT OK!")), "Messages are not matched")
+}
+
+func TestRequest_PayloadTooLarge(t *testing.T) {
+	assert := assert.New(t)
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(413)
+		fmt.Fprint(w, `{"error":"request entity too large"}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	_, err := newRequestConfig(&pc, "POST", "/v1/config/view", viewRequest{Name: "test"}).MakeRequest()
+	assert.EqualError(
+		err,
+		fmt.Sprintf(
+			`POST %s/v1/config/view, status 413 NOT OK! The request body is too large for the API; split the resource into smaller ones, e.g. fewer apps, hosts, categories or channels per view, or fewer exclusion rules. {"error":"request entity too large"}`,
+			ts.URL,
+		),
+		"The guidance is given",
+	)
+	assert.Equal(1, requests, "The request is not retried")
+
+	d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{"name": "test", "query": "test"})
+	diags := resourceViewCreate(context.Background(), d, &pc)
+	assert.True(diags.HasError(), "Expected error")
+	assert.Contains(diags[0].Summary, "split the resource into smaller ones", "The guidance reaches the diagnostic")
+}
+
+func TestRequest_MakeRequestWithContext(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("Aborts the call in flight when cancelled", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer slow.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		pc := providerConfig{baseURL: slow.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		start := time.Now()
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequestWithContext(ctx)
+		assert.True(errors.Is(err, context.Canceled), "The cancellation is returned: %v", err)
+		assert.True(time.Since(start) < time.Second, "Request was aborted")
+	})
+
+	t.Run("Stops waiting to retry when cancelled", func(t *testing.T) {
+		calls := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(503)
+		}))
+		defer ts.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		start := time.Now()
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, setRetryPolicy(3, time.Minute, time.Minute)).MakeRequestWithContext(ctx)
+		assert.True(errors.Is(err, context.Canceled), "The cancellation is returned: %v", err)
+		assert.Contains(err.Error(), "retry aborted", "The retry is named")
+		assert.Contains(err.Error(), "status 503 NOT OK!", "The last error is kept")
+		assert.Equal(1, calls, "Not retried")
+		assert.True(time.Since(start) < time.Second, "The wait was aborted")
+	})
+
+	t.Run("Creates the request with the context", func(t *testing.T) {
+		type key struct{}
+		ctx := context.WithValue(context.Background(), key{}, "op")
+		pc := providerConfig{baseURL: "http://localhost"}
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, func(req *requestConfig) {
+			req.httpRequest = func(reqCtx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+				assert.Equal("op", reqCtx.Value(key{}), "The context is passed")
+				return nil, errors.New("FAKE ERROR for http.NewRequestWithContext")
+			}
+		}).MakeRequestWithContext(ctx)
+		assert.EqualError(err, "FAKE ERROR for http.NewRequestWithContext", "The error is returned")
+	})
+}
+
+func TestRequest_ConfirmDestroy(t *testing.T) {
+	assert := assert.New(t)
+	var methods []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+	}))
+	defer ts.Close()
+	defer os.Unsetenv(allowDestroyEnv)
+
+	pc := providerConfig{
+		baseURL:        ts.URL,
+		httpClient:     &http.Client{Timeout: 15 * time.Second},
+		confirmDestroy: true,
+	}
+
+	t.Run("Blocks deletes without the environment variable", func(t *testing.T) {
+		os.Unsetenv(allowDestroyEnv)
+		_, err := newRequestConfig(&pc, "DELETE", "/v1/config/view/abc", nil).MakeRequest()
+		assert.EqualError(
+			err,
+			fmt.Sprintf("DELETE %s/v1/config/view/abc was blocked: confirm_destroy is enabled, set LOGDNA_ALLOW_DESTROY=1 to allow deletes", ts.URL),
+			"The guidance is given",
+		)
+		assert.Empty(methods, "No request is issued")
+
+		_, err = newRequestConfig(&pc, "GET", "/v1/config/view/abc", nil).MakeRequest()
+		assert.Nil(err, "Other methods are allowed")
+		assert.Equal([]string{"GET"}, methods, "The request is issued")
+	})
+
+	t.Run("Allows deletes with the environment variable", func(t *testing.T) {
+		os.Setenv(allowDestroyEnv, "1")
+		methods = nil
+		_, err := newRequestConfig(&pc, "DELETE", "/v1/config/view/abc", nil).MakeRequest()
+		assert.Nil(err, "No errors")
+		assert.Equal([]string{"DELETE"}, methods, "The request is issued")
+	})
+
+	t.Run("Allows deletes when disabled", func(t *testing.T) {
+		os.Unsetenv(allowDestroyEnv)
+		pc.confirmDestroy = false
+		methods = nil
+		_, err := newRequestConfig(&pc, "DELETE", "/v1/config/view/abc", nil).MakeRequest()
+		assert.Nil(err, "No errors")
+		assert.Equal([]string{"DELETE"}, methods, "The request is issued")
+	})
+}
+
+func TestRequest_RedactsServiceKey(t *testing.T) {
+	assert := assert.New(t)
+	const knownKey = "s3cr3t/key+123"
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	t.Run("From the errors of the client", func(t *testing.T) {
+		pc := providerConfig{serviceKey: knownKey, baseURL: "http://localhost"}
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, func(req *requestConfig) {
+			req.httpClient = &echoClient{}
+		}).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "FAKE ERROR calling httpClient.Do", "The error is kept")
+		assert.Contains(err.Error(), redacted, "The key is masked")
+		assert.NotContains(err.Error(), knownKey, "The key is not echoed")
+	})
+
+	t.Run("From the body of a failed response reflecting the header", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(400)
+			fmt.Fprintf(w, `{"error": "invalid key %s", "escaped": %q}`, r.Header.Get("servicekey"), url.QueryEscape(r.Header.Get("servicekey")))
+		}))
+		defer ts.Close()
+		pc := providerConfig{serviceKey: knownKey, baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "status 400 NOT OK!", "The error is kept")
+		assert.NotContains(err.Error(), knownKey, "The key is not echoed")
+		assert.NotContains(err.Error(), url.QueryEscape(knownKey), "The escaped key is not echoed")
+		var apiErr *APIError
+		assert.True(errors.As(err, &apiErr), "The error is still an APIError")
+	})
+
+	t.Run("From the logs of the retries", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "0") // Retried without waiting
+			w.WriteHeader(500)
+			fmt.Fprintf(w, `{"error": "temporarily unavailable for %s"}`, r.Header.Get("servicekey"))
+		}))
+		defer ts.Close()
+		pc := providerConfig{
+			serviceKey:    knownKey,
+			baseURL:       ts.URL,
+			httpClient:    &http.Client{Timeout: 15 * time.Second},
+			retryMessages: []string{"temporarily unavailable"},
+		}
+
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/view", nil, setRetryPolicy(1, time.Millisecond, time.Millisecond)).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.NotContains(err.Error(), knownKey, "The key is not echoed")
+		assert.Contains(buf.String(), "retrying in", "The retry is logged")
+		assert.NotContains(buf.String(), knownKey, "The key is not logged")
+	})
+}
+
+func TestRequest_RequestHeaders(t *testing.T) {
+	assert := assert.New(t)
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		fmt.Fprint(w, `{"viewID": "abc", "name": "test", "query": "test"}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{
+		serviceKey: "abc123",
+		baseURL:    ts.URL,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		requestHeaders: map[string]string{
+			"X-LogDNA-Feature": "views-v2",
+			"Content-Type":     "text/plain",
+		},
+	}
+	d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{"name": "test", "query": "test"})
+	diags := resourceViewCreate(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("views-v2", received.Get("X-LogDNA-Feature"), "The feature flag is sent")
+	assert.Equal("application/json", received.Get("Content-Type"), "The headers of the provider are kept")
+	assert.Equal("abc123", received.Get("servicekey"), "The service key is kept")
+
+	for _, name := range []string{"servicekey", "ServiceKey", "authorization"} {
+		assert.EqualError(
+			validateRequestHeaders(map[string]string{name: "other"}),
+			fmt.Sprintf("request_headers cannot set the %s header, which authenticates the requests", name),
+			"%s is rejected", name,
+		)
+	}
+	assert.Nil(validateRequestHeaders(pc.requestHeaders), "Other headers are accepted")
+}
+
+func TestRequest_ResponseContentTypes(t *testing.T) {
+	assert := assert.New(t)
+	contentType, body := "", ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		if contentType == "" {
+			// Sent without a Content-Type rather than a sniffed one
+			w.Header()["Content-Type"] = nil
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+	pc := providerConfig{
+		baseURL:              ts.URL,
+		httpClient:           &http.Client{Timeout: 15 * time.Second},
+		responseContentTypes: defaultResponseContentTypes,
+	}
+
+	contentType, body = "text/html; charset=utf-8", `<html><body><form action="/login">Sign in</form></body></html>`
+	_, err := newRequestConfig(&pc, "GET", "/v1/config/view/abc", nil).MakeRequest()
+	assert.EqualError(
+		err,
+		fmt.Sprintf(
+			`GET %s/v1/config/view/abc, the response has Content-Type "text/html; charset=utf-8", expected one of: application/json. A proxy or gateway may have answered instead of the LogDNA API, e.g. with a login page; otherwise add the type to response_content_types. Response: %s`,
+			ts.URL, body,
+		),
+		"The HTML page is rejected before being decoded",
+	)
+
+	body = "<html>" + strings.Repeat("x", maxBodyExcerpt) + "</html>"
+	_, err = newRequestConfig(&pc, "GET", "/v1/config/view/abc", nil).MakeRequest()
+	assert.True(strings.HasSuffix(err.Error(), "Response: <html>"+strings.Repeat("x", maxBodyExcerpt-6)+"..."), "Long bodies are truncated")
+
+	for _, accepted := range []string{"application/json", "Application/JSON; charset=utf-8", ""} {
+		contentType, body = accepted, `{"viewID": "abc"}`
+		_, err = newRequestConfig(&pc, "GET", "/v1/config/view/abc", nil).MakeRequest()
+		assert.Nil(err, "%q is accepted", accepted)
+	}
+
+	pc.responseContentTypes = []string{"application/json", "application/x-ndjson", "text/*"}
+	for _, accepted := range []string{"application/x-ndjson", "text/plain"} {
+		contentType, body = accepted, "{}\n{}\n"
+		_, err = newRequestConfig(&pc, "GET", "/v1/export", nil).MakeRequest()
+		assert.Nil(err, "%q is allowed", accepted)
+	}
+}
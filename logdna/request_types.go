@@ -7,11 +7,24 @@ package logdna
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"net/mail"
+	"reflect"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// redacted replaces secrets in logged output
+const redacted = "***REDACTED***"
+
+// schemaGetter is satisfied by both *schema.ResourceData and *schema.ResourceDiff
+// so that request bodies can also be built while planning
+type schemaGetter interface {
+	Get(string) interface{}
+}
+
 type viewRequest struct {
 	Apps     []string         `json:"apps,omitempty"`
 	Category []string         `json:"category,omitempty"`
@@ -19,6 +32,7 @@ type viewRequest struct {
 	Hosts    []string         `json:"hosts,omitempty"`
 	Levels   []string         `json:"levels,omitempty"`
 	Name     string           `json:"name,omitempty"`
+	Match    string           `json:"match,omitempty"`
 	Query    string           `json:"query,omitempty"`
 	Tags     []string         `json:"tags,omitempty"`
 	PresetId string           `json:"presetid,omitempty"`
@@ -29,7 +43,26 @@ type alertRequest struct {
 	Channels []channelRequest `json:"channels,omitempty"`
 }
 
+// anomalyConfig is the anomaly detection of a channel, used by index rate
+// alerts and anomaly views. It is sent and returned in the same shape.
+type anomalyConfig struct {
+	Sensitivity string  `json:"sensitivity,omitempty"`
+	ZScore      float64 `json:"zscore,omitempty"`
+}
+
+var anomalySensitivities = []string{"low", "medium", "high"}
+
+// Bounds of the z-score, the number of standard deviations from the mean
+// rate beyond which an anomaly is reported
+const (
+	minZScore = 0.5
+	maxZScore = 10.0
+)
+
 type channelRequest struct {
+	Active          string                 `json:"active,omitempty"`
+	AlertID         string                 `json:"alertid,omitempty"`
+	Anomaly         *anomalyConfig         `json:"anomaly,omitempty"`
 	BodyTemplate    map[string]interface{} `json:"bodyTemplate,omitempty"`
 	Emails          []string               `json:"emails,omitempty"`
 	Headers         map[string]string      `json:"headers,omitempty"`
@@ -54,18 +87,31 @@ type keyRequest struct {
 	Name string `json:"name,omitempty"`
 }
 
-func (view *viewRequest) CreateRequestBody(d *schema.ResourceData) diag.Diagnostics {
+// viewTagsRequest only carries the tags of a view, sent with a PATCH so the
+// rest of the view is left untouched
+type viewTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type memberRequest struct {
+	Email  string   `json:"email,omitempty"`
+	Role   string   `json:"role"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+func (view *viewRequest) CreateRequestBody(d schemaGetter) diag.Diagnostics {
 	// This function pulls from the schema in preparation to JSON marshal
 	var diags diag.Diagnostics
 
 	// Scalars
 	view.Name = d.Get("name").(string)
-	view.Query = d.Get("query").(string)
+	view.Query = withExclusions(d.Get("query").(string), exclusionTerms(d))
+	view.Match = d.Get("match").(string)
 
-	// Simple arrays
-	view.Apps = listToStrings(d.Get("apps").([]interface{}))
+	// Simple arrays, whose negated entries are excluded by the query
+	view.Apps, _ = splitNegations(listToStrings(d.Get("apps").([]interface{})))
 	view.Category = listToStrings(d.Get("categories").([]interface{}))
-	view.Hosts = listToStrings(d.Get("hosts").([]interface{}))
+	view.Hosts, _ = splitNegations(listToStrings(d.Get("hosts").([]interface{})))
 	view.Levels = listToStrings(d.Get("levels").([]interface{}))
 	view.Tags = listToStrings(d.Get("tags").([]interface{}))
 
@@ -77,7 +123,7 @@ func (view *viewRequest) CreateRequestBody(d *schema.ResourceData) diag.Diagnost
 	return diags
 }
 
-func (alert *alertRequest) CreateRequestBody(d *schema.ResourceData) diag.Diagnostics {
+func (alert *alertRequest) CreateRequestBody(d schemaGetter) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	// Scalars
@@ -89,6 +135,27 @@ func (alert *alertRequest) CreateRequestBody(d *schema.ResourceData) diag.Diagno
 	return diags
 }
 
+// defaultAlertName derives the name of an alert configured without one from
+// the integrations and the settings of its channels, e.g.
+// "terraform-email-slack-alert-1a2b3c4d", so that the preset alerts of several
+// views do not collide. A preset alert is created before the views referencing
+// it, so their names are not known yet. The same channels always give the same
+// name.
+func defaultAlertName(channels []channelRequest) string {
+	parts := []string{"terraform"}
+	seen := make(map[string]bool)
+	for _, c := range channels {
+		if !seen[c.Integration] {
+			seen[c.Integration] = true
+			parts = append(parts, c.Integration)
+		}
+	}
+	parts = append(parts, "alert")
+	settings, _ := json.Marshal(channels)
+	parts = append(parts, fmt.Sprintf("%08x", schema.HashString(string(settings))))
+	return strings.Join(parts, "-")
+}
+
 func (category *categoryRequest) CreateRequestBody(d *schema.ResourceData) diag.Diagnostics {
 	var diags diag.Diagnostics
 
@@ -107,8 +174,126 @@ func (key *keyRequest) CreateRequestBody(d *schema.ResourceData) diag.Diagnostic
 	return diags
 }
 
+func (member *memberRequest) CreateRequestBody(d *schema.ResourceData) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	// Scalars
+	member.Email = d.Get("email").(string)
+	member.Role = d.Get("role").(string)
+	member.Groups = listToStrings(d.Get("groups").([]interface{}))
+
+	return diags
+}
+
+// maxChannels is the number of channels LogDNA accepts for a view or a preset
+// alert; beyond it the API fails with an opaque error
+const maxChannels = 20
+
+// bodyMethods are the webhook methods which send a body
+var bodyMethods = []string{"post", "put", "patch"}
+
+// defaultWebhookMethod is the method the API saves for a webhook without one
+const defaultWebhookMethod = "post"
+
+// suppressDefaultWebhookMethod ignores the method the API fills in for a
+// webhook configured without one, and differences of case, e.g. after an
+// import
+func suppressDefaultWebhookMethod(k, old, new string, d *schema.ResourceData) bool {
+	if new == "" {
+		new = defaultWebhookMethod
+	}
+	return strings.EqualFold(old, new)
+}
+
+// validateWebhookBodyTemplates rejects the webhooks with a bodytemplate and a
+// method which sends no body, e.g. get
+func validateWebhookBodyTemplates(d schemaGetter) error {
+	for i, entry := range d.Get("webhook_channel").([]interface{}) {
+		webhook, ok := entry.(map[string]interface{})
+		if !ok || webhook["bodytemplate"].(string) == "" {
+			continue
+		}
+		method := strings.ToLower(webhook["method"].(string))
+		if method == "" {
+			method = defaultWebhookMethod
+		}
+		sendsBody := false
+		for _, m := range bodyMethods {
+			sendsBody = sendsBody || m == method
+		}
+		if !sendsBody {
+			return fmt.Errorf(
+				"webhook_channel.%d: bodytemplate cannot be set with method %q, which sends no body; use one of %s",
+				i, webhook["method"], strings.Join(bodyMethods, ", "),
+			)
+		}
+	}
+	return nil
+}
+
+// dropShiftedChannelIDs clears the IDs which an update would send for the
+// wrong channel. The computed channelid stays at its index in the state, so
+// when channels of an integration are added or removed, e.g. a channel moved
+// from slack_channel to webhook_channel, the next channels of the list inherit
+// the IDs of the previous ones and the API would update those in place with
+// another configuration. They are sent without ID instead, so the API replaces
+// them; the channels left unchanged keep their ID.
+func dropShiftedChannelIDs(d *schema.ResourceData, channels []channelRequest) {
+	stale := map[string]bool{}
+	for _, integration := range []string{EMAIL, PAGERDUTY, SLACK, WEBHOOK} {
+		o, n := d.GetChange(integration + "_channel")
+		oldChannels, newChannels := o.([]interface{}), n.([]interface{})
+		if len(oldChannels) == len(newChannels) {
+			continue
+		}
+		for i := 0; i < len(oldChannels) && i < len(newChannels); i++ {
+			oldChannel := oldChannels[i].(map[string]interface{})
+			if !sameChannelConfig(oldChannel, newChannels[i].(map[string]interface{})) {
+				stale[oldChannel["channelid"].(string)] = true
+			}
+		}
+	}
+	delete(stale, "")
+
+	for i := range channels {
+		if stale[channels[i].AlertID] {
+			log.Printf("[DEBUG] Channel %s was shifted by added or removed channels, it is replaced", channels[i].AlertID)
+			channels[i].AlertID = ""
+		}
+	}
+}
+
+// sameChannelConfig compares two channel entries regardless of their channelid
+func sameChannelConfig(a, b map[string]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if k == "channelid" {
+			continue
+		}
+		if !reflect.DeepEqual(v, b[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateChannelCount rejects configurations with more channels than the API
+// accepts, all integrations combined
+func validateChannelCount(d schemaGetter) error {
+	count := 0
+	for _, integration := range []string{EMAIL, PAGERDUTY, SLACK, WEBHOOK} {
+		count += len(d.Get(integration + "_channel").([]interface{}))
+	}
+	if count > maxChannels {
+		return fmt.Errorf("%d channels are configured but LogDNA accepts at most %d per view or alert", count, maxChannels)
+	}
+	return nil
+}
+
 func aggregateAllChannelsFromSchema(
-	d *schema.ResourceData,
+	d schemaGetter,
 	diags *diag.Diagnostics,
 ) *[]channelRequest {
 	allChannelEntries := make([]channelRequest, 0)
@@ -199,11 +384,14 @@ func emailChannelRequest(s map[string]interface{}) channelRequest {
 
 	c := channelRequest{
 		Emails:          emails,
+		Active:          s["enabled"].(string),
+		AlertID:         s["channelid"].(string),
+		Anomaly:         anomalyRequest(s),
 		Immediate:       s["immediate"].(string),
 		Integration:     EMAIL,
 		Operator:        s["operator"].(string),
 		Terminal:        s["terminal"].(string),
-		TriggerInterval: s["triggerinterval"].(string),
+		TriggerInterval: normalizeTriggerInterval(s["triggerinterval"].(string)),
 		TriggerLimit:    s["triggerlimit"].(int),
 		Timezone:        s["timezone"].(string),
 	}
@@ -211,14 +399,128 @@ func emailChannelRequest(s map[string]interface{}) channelRequest {
 	return c
 }
 
+// anomalyRequest returns the anomaly detection settings of a channel, if any
+func anomalyRequest(s map[string]interface{}) *anomalyConfig {
+	anomaly := anomalyConfig{
+		Sensitivity: s["sensitivity"].(string),
+		ZScore:      s["zscore"].(float64),
+	}
+	if anomaly == (anomalyConfig{}) {
+		return nil
+	}
+	return &anomaly
+}
+
+// triggerIntervalPresets are the names of the intervals in the LogDNA UI
+// dropdown, in the same order, with the values accepted by the API
+var triggerIntervalPresets = []struct{ name, interval string }{
+	{"30sec", "30"},
+	{"1min", "1m"},
+	{"5min", "5m"},
+	{"15min", "15m"},
+	{"30min", "30m"},
+	{"1hour", "1h"},
+	{"6hours", "6h"},
+	{"12hours", "12h"},
+	{"24hours", "24h"},
+}
+
+// triggerIntervals are the intervals accepted by the API for each operator
+var triggerIntervals = map[string][]string{
+	"presence": {"30", "1m", "5m", "15m", "30m", "1h", "6h", "12h", "24h"},
+	"absence":  {"15m", "30m", "1h", "6h", "12h", "24h"},
+}
+
+// normalizeTriggerInterval maps a preset to the interval accepted by the API;
+// other values are returned unchanged
+func normalizeTriggerInterval(interval string) string {
+	for _, p := range triggerIntervalPresets {
+		if p.name == interval {
+			return p.interval
+		}
+	}
+	return interval
+}
+
+func triggerIntervalState(val interface{}) string {
+	return normalizeTriggerInterval(val.(string))
+}
+
+// validateTriggerInterval rejects the values which are neither an interval of
+// the presence operator, the widest set, nor a preset. The intervals of the
+// absence operator are checked by validateTriggerIntervals.
+func validateTriggerInterval(val interface{}, key string) (warns []string, errs []error) {
+	v := val.(string)
+	for _, interval := range triggerIntervals["presence"] {
+		if normalizeTriggerInterval(v) == interval {
+			return
+		}
+	}
+	names := make([]string, 0, len(triggerIntervalPresets))
+	for _, p := range triggerIntervalPresets {
+		names = append(names, p.name)
+	}
+	errs = append(errs, fmt.Errorf(
+		"%q must be one of [%s] or one of the presets [%s], got: %q",
+		key, strings.Join(triggerIntervals["presence"], ", "), strings.Join(names, ", "), v,
+	))
+	return
+}
+
+// validateTriggerIntervals rejects the channels whose triggerinterval is not
+// accepted for their operator, e.g. 5m for absence
+func validateTriggerIntervals(d schemaGetter) error {
+	for _, integration := range []string{EMAIL, PAGERDUTY, SLACK, WEBHOOK} {
+		key := fmt.Sprintf("%s_channel", integration)
+		for i, entry := range d.Get(key).([]interface{}) {
+			channel, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			interval := normalizeTriggerInterval(channel["triggerinterval"].(string))
+			allowed, ok := triggerIntervals[channel["operator"].(string)]
+			if interval == "" || !ok {
+				continue
+			}
+			valid := false
+			for _, a := range allowed {
+				valid = valid || a == interval
+			}
+			if !valid {
+				return fmt.Errorf(
+					"%s.%d: triggerinterval %q is not allowed with the %s operator; use one of %s",
+					key, i, channel["triggerinterval"], channel["operator"], strings.Join(allowed, ", "),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// validateEmailAddress rejects malformed entries of email_channel.emails; the
+// key names the offending entry, e.g. email_channel.0.emails.1
+func validateEmailAddress(val interface{}, key string) (warns []string, errs []error) {
+	v := val.(string)
+	addr, err := mail.ParseAddress(v)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%q must be a valid email address, got: %q (%s)", key, v, err))
+	} else if addr.Address != v {
+		errs = append(errs, fmt.Errorf("%q must be a bare email address without a display name, got: %q", key, v))
+	}
+	return
+}
+
 func pagerDutyChannelRequest(s map[string]interface{}) channelRequest {
 	c := channelRequest{
+		Active:          s["enabled"].(string),
+		AlertID:         s["channelid"].(string),
+		Anomaly:         anomalyRequest(s),
 		Immediate:       s["immediate"].(string),
 		Integration:     PAGERDUTY,
 		Key:             s["key"].(string),
 		Operator:        s["operator"].(string),
 		Terminal:        s["terminal"].(string),
-		TriggerInterval: s["triggerinterval"].(string),
+		TriggerInterval: normalizeTriggerInterval(s["triggerinterval"].(string)),
 		TriggerLimit:    s["triggerlimit"].(int),
 	}
 
@@ -227,11 +529,14 @@ func pagerDutyChannelRequest(s map[string]interface{}) channelRequest {
 
 func slackChannelRequest(s map[string]interface{}) channelRequest {
 	c := channelRequest{
+		Active:          s["enabled"].(string),
+		AlertID:         s["channelid"].(string),
+		Anomaly:         anomalyRequest(s),
 		Immediate:       s["immediate"].(string),
 		Integration:     SLACK,
 		Operator:        s["operator"].(string),
 		Terminal:        s["terminal"].(string),
-		TriggerInterval: s["triggerinterval"].(string),
+		TriggerInterval: normalizeTriggerInterval(s["triggerinterval"].(string)),
 		TriggerLimit:    s["triggerlimit"].(int),
 		URL:             s["url"].(string),
 	}
@@ -245,14 +550,21 @@ func webHookChannelRequest(s map[string]interface{}, diags *diag.Diagnostics) ch
 	for k, v := range s["headers"].(map[string]interface{}) {
 		headersMap[k] = v.(string)
 	}
+	// The secret is write-only and sent as a header of its own
+	if secret := s["secret"].(string); secret != "" {
+		headersMap[s["secretheader"].(string)] = secret
+	}
 
 	c := channelRequest{
 		Headers:         headersMap,
+		Active:          s["enabled"].(string),
+		AlertID:         s["channelid"].(string),
+		Anomaly:         anomalyRequest(s),
 		Immediate:       s["immediate"].(string),
 		Integration:     WEBHOOK,
 		Operator:        s["operator"].(string),
 		Method:          s["method"].(string),
-		TriggerInterval: s["triggerinterval"].(string),
+		TriggerInterval: normalizeTriggerInterval(s["triggerinterval"].(string)),
 		TriggerLimit:    s["triggerlimit"].(int),
 		URL:             s["url"].(string),
 		Terminal:        s["terminal"].(string),
@@ -277,6 +589,24 @@ func webHookChannelRequest(s map[string]interface{}, diags *diag.Diagnostics) ch
 	return c
 }
 
+// previewRequestBody logs the body that will be sent for a resource. It is used
+// during plan so that the channel serialization can be verified before applying;
+// its credentials are masked like those of the request bodies logged in debug.
+func previewRequestBody(resourceType string, name string, body interface{}) {
+	preview, err := json.Marshal(body)
+	if err == nil {
+		var v interface{}
+		if err = json.Unmarshal(preview, &v); err == nil {
+			preview, err = json.Marshal(redactBody(v))
+		}
+	}
+	if err != nil {
+		log.Printf("[WARN] Cannot preview the request body for %s %q: %s", resourceType, name, err)
+		return
+	}
+	log.Printf("[DEBUG] Planned request body for %s %q: %s", resourceType, name, preview)
+}
+
 func listToStrings(list []interface{}) []string {
 	strs := make([]string, 0, len(list))
 	for _, elem := range list {
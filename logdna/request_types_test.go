@@ -1,9 +1,21 @@
 package logdna
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,3 +41,320 @@ func TestRequestTypes_iterateIntegrationType(t *testing.T) {
 		assert.Equal("Unrecognized integration: NOPE", err.Detail, "Detail")
 	})
 }
+
+func TestRequestTypes_redactionIsShared(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("TF_LOG", "DEBUG")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"viewID": "abc", "name": "redacted"}`)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	raw := map[string]interface{}{
+		"name": "redacted",
+		"pagerduty_channel": []interface{}{
+			map[string]interface{}{"key": "pd-secret", "triggerlimit": 15},
+		},
+		"slack_channel": []interface{}{
+			map[string]interface{}{"url": "https://hooks.slack.com/services/identifier/secret", "triggerlimit": 15},
+		},
+		"webhook_channel": []interface{}{
+			map[string]interface{}{
+				"url":          "https://yourwebhook/endpoint",
+				"headers":      map[string]interface{}{"Authorization": "Bearer token"},
+				"triggerlimit": 15,
+			},
+		},
+	}
+	_, err := resourceView().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), nil)
+	assert.Nil(err, "No errors")
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	diags := resourceViewCreate(context.Background(), schema.TestResourceDataRaw(t, resourceView().Schema, raw), &pc)
+	assert.False(diags.HasError(), "No errors")
+
+	// Both log sites mask the same fields of the same body
+	out := buf.String()
+	decodeLogged := func(prefix string) map[string]interface{} {
+		i := strings.Index(out, prefix)
+		if !assert.True(i >= 0, "%q is logged", prefix) {
+			return nil
+		}
+		var body map[string]interface{}
+		assert.Nil(json.NewDecoder(strings.NewReader(out[i+len(prefix):])).Decode(&body), "The body is JSON")
+		return body
+	}
+	planned := decodeLogged(`Planned request body for logdna_view "redacted": `)
+	sent := decodeLogged("request body:\n")
+	assert.Equal(planned, sent, "The plan preview and the request log are masked alike")
+
+	channels, _ := sent["channels"].([]interface{})
+	assert.Len(channels, 3, "Every channel is logged")
+	for _, c := range channels {
+		channel := c.(map[string]interface{})
+		switch channel["integration"] {
+		case PAGERDUTY:
+			assert.Equal(redacted, channel["key"], "PagerDuty key is masked")
+		case SLACK:
+			assert.Equal(redacted, channel["url"], "Slack URL is masked")
+		case WEBHOOK:
+			assert.Equal("https://yourwebhook/endpoint", channel["url"], "Webhook URL is kept")
+			assert.Equal(map[string]interface{}{"Authorization": redacted}, channel["headers"], "Webhook header values are masked")
+		}
+	}
+	for _, secret := range []string{"pd-secret", "hooks.slack.com", "Bearer token"} {
+		assert.NotContains(out, secret, "%s is not logged", secret)
+	}
+}
+
+func TestRequestTypes_previewRequestBody(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":  "preview",
+		"query": "level:error",
+		"pagerduty_channel": []interface{}{
+			map[string]interface{}{"key": "pd-secret", "triggerlimit": 15},
+		},
+		"webhook_channel": []interface{}{
+			map[string]interface{}{
+				"url":          "https://yourwebhook/endpoint",
+				"headers":      map[string]interface{}{"Authorization": "Bearer token"},
+				"bodytemplate": `{"message": "{{name}}"}`,
+				"triggerlimit": 15,
+			},
+		},
+	})
+	_, err := resourceView().Diff(context.Background(), nil, cfg, nil)
+	assert.Nil(err, "No errors")
+
+	out := buf.String()
+	assert.Contains(out, `[DEBUG] Planned request body for logdna_view "preview": `, "Preview is logged")
+	assert.Contains(out, `"integration":"pagerduty","key":"***REDACTED***"`, "PagerDuty channel is serialized and masked")
+	assert.Contains(out, `"bodyTemplate":{"message":"{{name}}"}`, "Body template is serialized")
+	assert.Contains(out, `"headers":{"Authorization":"***REDACTED***"}`, "Headers are masked")
+	assert.NotContains(out, "pd-secret", "PagerDuty key is not logged")
+	assert.NotContains(out, "Bearer token", "Header values are not logged")
+}
+
+func TestRequestTypes_channelEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{
+		"name": "toggle",
+		"slack_channel": []interface{}{
+			map[string]interface{}{"url": "https://hooks.slack.com/a", "triggerlimit": 15},
+			map[string]interface{}{"url": "https://hooks.slack.com/b", "triggerlimit": 15, "enabled": "false"},
+		},
+	})
+	view := viewRequest{}
+	diags := view.CreateRequestBody(d)
+	assert.False(diags.HasError(), "No errors")
+
+	assert.Len(view.Channels, 2, "Both channels are kept")
+	assert.Equal("true", view.Channels[0].Active, "Channels are enabled by default")
+	assert.Equal("false", view.Channels[1].Active, "The disabled channel is sent as inactive")
+	assert.Equal("https://hooks.slack.com/b", view.Channels[1].URL, "The disabled channel keeps its configuration")
+
+	t.Run("Rejects values other than true and false at plan time", func(t *testing.T) {
+		for name, rs := range map[string]*schema.Resource{"view": resourceView(), "alert": resourceAlert()} {
+			diags := rs.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":  "test",
+				"query": "test",
+				"slack_channel": []interface{}{map[string]interface{}{
+					"url":     "https://hooks.slack.com/a",
+					"enabled": "flase",
+				}},
+			}))
+			assert.True(diags.HasError(), "%s: Expected error", name)
+			assert.Contains(diags[0].Summary, `expected slack_channel.0.enabled to be one of [true false], got flase`, "%s: Summary", name)
+		}
+	})
+}
+
+func TestRequestTypes_validateEmailAddress(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, email := range []string{"test@logdna.com", "first.last+alerts@sub.example.org"} {
+		_, errs := validateEmailAddress(email, "email_channel.0.emails.0")
+		assert.Empty(errs, "%q is valid", email)
+	}
+
+	for _, email := range []string{"", "not-an-email", "missing@", "@logdna.com", "Test User <test@logdna.com>"} {
+		_, errs := validateEmailAddress(email, "email_channel.0.emails.1")
+		assert.Len(errs, 1, "%q is invalid", email)
+		assert.Contains(errs[0].Error(), `"email_channel.0.emails.1"`, "The offending entry is named")
+	}
+
+	t.Run("Rejects invalid addresses at plan time", func(t *testing.T) {
+		for name, rs := range map[string]*schema.Resource{"view": resourceView(), "alert": resourceAlert()} {
+			diags := rs.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":  "test",
+				"query": "test",
+				"email_channel": []interface{}{map[string]interface{}{
+					"emails": []interface{}{"test@logdna.com", "nope"},
+				}},
+			}))
+			assert.True(diags.HasError(), "%s: Expected error", name)
+			summaries := make([]string, 0, len(diags))
+			for _, d := range diags {
+				summaries = append(summaries, d.Summary)
+			}
+			assert.Contains(
+				strings.Join(summaries, "\n"),
+				`"email_channel.0.emails.1" must be a valid email address`,
+				"%s: Summary",
+				name,
+			)
+		}
+	})
+}
+
+func TestRequestTypes_triggerIntervalPresets(t *testing.T) {
+	assert := assert.New(t)
+
+	for preset, interval := range map[string]string{
+		"30sec":   "30",
+		"1min":    "1m",
+		"5min":    "5m",
+		"15min":   "15m",
+		"30min":   "30m",
+		"1hour":   "1h",
+		"6hours":  "6h",
+		"12hours": "12h",
+		"24hours": "24h",
+		"15m":     "15m",
+		"30":      "30",
+	} {
+		assert.Equal(interval, normalizeTriggerInterval(preset), "%q is normalized", preset)
+		_, errs := validateTriggerInterval(preset, "email_channel.0.triggerinterval")
+		assert.Empty(errs, "%q is valid", preset)
+	}
+
+	for _, preset := range []string{"7min", "2hours", "45sec"} {
+		_, errs := validateTriggerInterval(preset, "email_channel.0.triggerinterval")
+		assert.Len(errs, 1, "%q is an unknown preset", preset)
+		assert.Contains(errs[0].Error(), "[30sec, 1min, 5min", "Presets are listed")
+	}
+
+	for _, interval := range []string{"abc", "7", "45", "2h", "30s", ""} {
+		_, errs := validateTriggerInterval(interval, "email_channel.0.triggerinterval")
+		assert.Len(errs, 1, "%q is not an allowed interval", interval)
+		assert.Contains(errs[0].Error(), "must be one of [30, 1m, 5m, 15m", "Intervals are listed")
+	}
+
+	t.Run("Checks the intervals of the operator", func(t *testing.T) {
+		channels := func(operator string, interval string) map[string]interface{} {
+			return map[string]interface{}{
+				"name": "test",
+				"slack_channel": []interface{}{map[string]interface{}{
+					"url":             "https://hooks.slack.com/services/identifier/secret",
+					"operator":        operator,
+					"triggerinterval": interval,
+				}},
+			}
+		}
+		for _, interval := range []string{"15m", "15min", "24hours"} {
+			d := schema.TestResourceDataRaw(t, resourceView().Schema, channels("absence", interval))
+			assert.Nil(validateTriggerIntervals(d), "%q is allowed for absence", interval)
+		}
+		d := schema.TestResourceDataRaw(t, resourceView().Schema, channels("presence", "30sec"))
+		assert.Nil(validateTriggerIntervals(d), "30sec is allowed for presence")
+
+		d = schema.TestResourceDataRaw(t, resourceView().Schema, channels("absence", "5min"))
+		assert.EqualError(
+			validateTriggerIntervals(d),
+			`slack_channel.0: triggerinterval "5min" is not allowed with the absence operator; use one of 15m, 30m, 1h, 6h, 12h, 24h`,
+			"Short intervals are rejected for absence",
+		)
+	})
+
+	t.Run("Sends the interval accepted by the API", func(t *testing.T) {
+		d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{
+			"name":  "test",
+			"query": "test",
+			"email_channel": []interface{}{map[string]interface{}{
+				"emails":          []interface{}{"test@logdna.com"},
+				"triggerinterval": "1hour",
+			}},
+		})
+		view := viewRequest{}
+		assert.False(view.CreateRequestBody(d).HasError(), "No errors")
+		assert.Equal("1h", view.Channels[0].TriggerInterval, "Preset is normalized")
+	})
+}
+
+func TestRequestTypes_validateChannelCount(t *testing.T) {
+	assert := assert.New(t)
+
+	// channels splits count channels between the email and webhook blocks
+	channels := func(count int) map[string]interface{} {
+		emails, webhooks := []interface{}{}, []interface{}{}
+		for i := 0; i < count; i++ {
+			if i%2 == 0 {
+				emails = append(emails, map[string]interface{}{"emails": []interface{}{"test@logdna.com"}})
+			} else {
+				webhooks = append(webhooks, map[string]interface{}{"url": "https://example.com", "triggerinterval": "30"})
+			}
+		}
+		return map[string]interface{}{
+			"name":            "test",
+			"query":           "test",
+			"email_channel":   emails,
+			"webhook_channel": webhooks,
+		}
+	}
+
+	for name, rs := range map[string]*schema.Resource{"logdna_view": resourceView(), "logdna_alert": resourceAlert()} {
+		t.Run(name, func(t *testing.T) {
+			_, err := rs.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(channels(maxChannels)), &providerConfig{})
+			assert.Nil(err, "The maximum is accepted")
+
+			_, err = rs.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(channels(maxChannels+1)), &providerConfig{})
+			assert.EqualError(err, "21 channels are configured but LogDNA accepts at most 20 per view or alert", "One over the maximum is rejected")
+		})
+	}
+}
+
+func TestRequestTypes_validateWebhookBodyTemplates(t *testing.T) {
+	assert := assert.New(t)
+
+	webhook := func(method string, bodyTemplate string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":  "test",
+			"query": "test",
+			"webhook_channel": []interface{}{
+				map[string]interface{}{"url": "https://example.com", "method": "post", "bodytemplate": `{"a": 1}`},
+				map[string]interface{}{"url": "https://example.com", "method": method, "bodytemplate": bodyTemplate},
+			},
+		}
+	}
+
+	for name, rs := range map[string]*schema.Resource{"logdna_view": resourceView(), "logdna_alert": resourceAlert()} {
+		t.Run(name, func(t *testing.T) {
+			_, err := rs.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(webhook("get", `{"message": "{{ line }}"}`)), &providerConfig{})
+			assert.EqualError(
+				err,
+				`webhook_channel.1: bodytemplate cannot be set with method "get", which sends no body; use one of post, put, patch`,
+				"A GET webhook with a body template is rejected",
+			)
+
+			_, err = rs.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(webhook("DELETE", `{"message": "{{ line }}"}`)), &providerConfig{})
+			assert.Error(err, "Methods are matched case-insensitively")
+
+			for _, method := range []string{"put", "PATCH", ""} {
+				_, err = rs.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(webhook(method, `{"message": "{{ line }}"}`)), &providerConfig{})
+				assert.Nil(err, "A body template is accepted with method %q", method)
+			}
+			_, err = rs.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(webhook("get", "")), &providerConfig{})
+			assert.Nil(err, "A GET webhook without body template is accepted")
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceAlertCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -20,29 +21,34 @@ func resourceAlertCreate(ctx context.Context, d *schema.ResourceData, m interfac
 	if diags = alert.CreateRequestBody(d); diags.HasError() {
 		return diags
 	}
+	// NOTE Once created, the name is read back into the state so it is only
+	//      generated on the first request and never changes afterwards
+	if alert.Name == "" {
+		alert.Name = defaultAlertName(alert.Channels)
+	}
 
 	req := newRequestConfig(
 		pc,
 		"POST",
-		"/v1/config/presetalert",
+		pc.endpoint("alert.create"),
 		alert,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_alert", d, err))
 	}
 
 	createdAlert := alertResponse{}
 	err = json.Unmarshal(body, &createdAlert)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_alert", d, err))
 	}
 	log.Printf("[DEBUG] After %s presetalert, the created alert is %+v", req.method, createdAlert)
 
-	d.SetId(createdAlert.PresetID)
+	d.SetId(string(createdAlert.PresetID))
 
 	return resourceAlertRead(ctx, d, m)
 }
@@ -56,18 +62,24 @@ func resourceAlertRead(ctx context.Context, d *schema.ResourceData, m interface{
 	req := newRequestConfig(
 		pc,
 		"GET",
-		fmt.Sprintf("/v1/config/presetalert/%s", presetID),
+		pc.endpoint("alert.read", presetID),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 
 	log.Printf("[DEBUG] GET presetalert raw response body %s\n", body)
+	if isNotFoundErr(err) {
+		// Deleted outside of Terraform, to be recreated by the plan
+		log.Printf("[WARN] Preset alert %s was not found, removing it from the state", presetID)
+		d.SetId("")
+		return diags
+	}
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot read the remote presetalert resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_alert", d, err).Error(),
 		})
 		return diags
 	}
@@ -78,11 +90,12 @@ func resourceAlertRead(ctx context.Context, d *schema.ResourceData, m interface{
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot unmarshal response from the remote presetalert resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_alert", d, err).Error(),
 		})
 		return diags
 	}
 	log.Printf("[DEBUG] The GET presetalert structure is as follows: %+v\n", alert)
+	ignored := ignoredFields(d)
 
 	// Top level keys can be set directly
 	appendError(d.Set("name", alert.Name), &diags)
@@ -95,8 +108,14 @@ func resourceAlertRead(ctx context.Context, d *schema.ResourceData, m interface{
 	// integrations since we have done a PUT operation. Thus, remove non-existing things.
 	for name, value := range integrations {
 		schemaKey := fmt.Sprintf("%s_channel", name)
+		prior := d.Get(schemaKey).([]interface{})
+		value = orderChannelsByID(prior, value)
+		if name == WEBHOOK {
+			value = keepWebhookSecrets(prior, value)
+		}
 		appendError(d.Set(schemaKey, value), &diags)
 	}
+	restoreIgnoredFields(d, ignored, &diags)
 
 	return diags
 }
@@ -114,15 +133,15 @@ func resourceAlertUpdate(ctx context.Context, d *schema.ResourceData, m interfac
 	req := newRequestConfig(
 		pc,
 		"PUT",
-		fmt.Sprintf("/v1/config/presetalert/%s", presetID),
+		pc.endpoint("alert.update", presetID),
 		alert,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("updating", "logdna_alert", d, err))
 	}
 
 	log.Printf("[DEBUG] %s %s SUCCESS. Remote resource updated.", req.method, req.apiURL)
@@ -137,47 +156,78 @@ func resourceAlertDelete(ctx context.Context, d *schema.ResourceData, m interfac
 	req := newRequestConfig(
 		pc,
 		"DELETE",
-		fmt.Sprintf("/v1/config/presetalert/%s", presetID),
+		pc.endpoint("alert.delete", presetID),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	log.Printf("[DEBUG] %s %s presetalert %s", req.method, req.apiURL, body)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return pc.deleteError(d, "logdna_alert", err)
 	}
 	d.SetId("")
 	return nil
 }
 
+func resourceAlertCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := validateChannelCount(d); err != nil {
+		return err
+	}
+	if err := validateWebhookBodyTemplates(d); err != nil {
+		return err
+	}
+	if err := validateTriggerIntervals(d); err != nil {
+		return err
+	}
+	alert := alertRequest{}
+	if diags := alert.CreateRequestBody(d); !diags.HasError() {
+		previewRequestBody("logdna_alert", alert.Name, alert)
+	}
+	return nil
+}
+
 func resourceAlert() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceAlertCreate,
 		ReadContext:   resourceAlertRead,
 		UpdateContext: resourceAlertUpdate,
 		DeleteContext: resourceAlertDelete,
+		CustomizeDiff: resourceAlertCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 
 		Schema: map[string]*schema.Schema{
+			"ignore_fields": ignoreFieldsSchema(append([]string{"name"}, channelFields...)),
 			"name": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				Computed: true,
 			},
 			"email_channel": {
 				Type:     schema.TypeList,
 				Optional: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"channelid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
 						"emails": {
 							Type:     schema.TypeList,
 							Required: true,
 							Elem: &schema.Schema{
-								Type: schema.TypeString,
+								Type:         schema.TypeString,
+								ValidateFunc: validateEmailAddress,
 							},
 						},
+						"enabled": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "true",
+							ValidateFunc: validation.StringInSlice([]string{"true", "false"}, false),
+						},
 						"immediate": {
 							Type:     schema.TypeString,
 							Optional: true,
@@ -187,6 +237,16 @@ func resourceAlert() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 						},
+						"sensitivity": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice(anomalySensitivities, false),
+						},
+						"zscore": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							ValidateFunc: validation.FloatBetween(minZScore, maxZScore),
+						},
 						"terminal": {
 							Type:     schema.TypeString,
 							Required: true,
@@ -196,12 +256,15 @@ func resourceAlert() *schema.Resource {
 							Optional: true,
 						},
 						"triggerinterval": {
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateTriggerInterval,
+							StateFunc:    triggerIntervalState,
 						},
 						"triggerlimit": {
 							Type:     schema.TypeInt,
-							Required: true,
+							Optional: true,
+							Computed: true,
 							ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
 								v := val.(int)
 								if v < 1 || v > 100000 {
@@ -218,6 +281,16 @@ func resourceAlert() *schema.Resource {
 				Optional: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"channelid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"enabled": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "true",
+							ValidateFunc: validation.StringInSlice([]string{"true", "false"}, false),
+						},
 						"immediate": {
 							Type:     schema.TypeString,
 							Optional: true,
@@ -232,18 +305,31 @@ func resourceAlert() *schema.Resource {
 							Optional: true,
 							Default:  "presence",
 						},
+						"sensitivity": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice(anomalySensitivities, false),
+						},
+						"zscore": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							ValidateFunc: validation.FloatBetween(minZScore, maxZScore),
+						},
 						"terminal": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "false",
 						},
 						"triggerinterval": {
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateTriggerInterval,
+							StateFunc:    triggerIntervalState,
 						},
 						"triggerlimit": {
 							Type:     schema.TypeInt,
-							Required: true,
+							Optional: true,
+							Computed: true,
 							ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
 								v := val.(int)
 								if v < 1 || v > 100000 {
@@ -260,6 +346,16 @@ func resourceAlert() *schema.Resource {
 				Optional: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"channelid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"enabled": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "true",
+							ValidateFunc: validation.StringInSlice([]string{"true", "false"}, false),
+						},
 						"immediate": {
 							Type:     schema.TypeString,
 							Optional: true,
@@ -270,18 +366,31 @@ func resourceAlert() *schema.Resource {
 							Optional: true,
 							Default:  "presence",
 						},
+						"sensitivity": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice(anomalySensitivities, false),
+						},
+						"zscore": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							ValidateFunc: validation.FloatBetween(minZScore, maxZScore),
+						},
 						"terminal": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "false",
 						},
 						"triggerinterval": {
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateTriggerInterval,
+							StateFunc:    triggerIntervalState,
 						},
 						"triggerlimit": {
 							Type:     schema.TypeInt,
-							Required: true,
+							Optional: true,
+							Computed: true,
 							ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
 								v := val.(int)
 								if v < 1 || v > 100000 {
@@ -302,6 +411,10 @@ func resourceAlert() *schema.Resource {
 				Optional: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"channelid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
 						"bodytemplate": {
 							Type:     schema.TypeString,
 							Optional: true,
@@ -330,32 +443,63 @@ func resourceAlert() *schema.Resource {
 							},
 							Optional: true,
 						},
+						"secret": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"secretheader": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      defaultWebhookSecretHeader,
+							ValidateFunc: validation.StringIsNotWhiteSpace,
+						},
+						"enabled": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "true",
+							ValidateFunc: validation.StringInSlice([]string{"true", "false"}, false),
+						},
 						"immediate": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "false",
 						},
 						"method": {
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:             schema.TypeString,
+							Optional:         true,
+							DiffSuppressFunc: suppressDefaultWebhookMethod,
 						},
 						"operator": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "presence",
 						},
+						"sensitivity": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice(anomalySensitivities, false),
+						},
+						"zscore": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							ValidateFunc: validation.FloatBetween(minZScore, maxZScore),
+						},
 						"terminal": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "false",
 						},
 						"triggerinterval": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateTriggerInterval,
+							StateFunc:    triggerIntervalState,
 						},
 						"triggerlimit": {
 							Type:     schema.TypeInt,
-							Required: true,
+							Optional: true,
+							Computed: true,
 							ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
 								v := val.(int)
 								if v < 1 || v > 100000 {
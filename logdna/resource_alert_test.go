@@ -1,10 +1,21 @@
 package logdna
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
 )
 
 var alertDefaults = cloneDefaults(rsDefaults["alert"])
@@ -17,22 +28,31 @@ func TestAlert_ErrorProviderUrl(t *testing.T) {
 		Steps: []resource.TestStep{
 			{
 				Config:      fmtTestConfigResource("alert", "new", pcArgs, alertDefaults, nilOpt, nilLst),
-				ExpectError: regexp.MustCompile("Error: error during HTTP request: Post \"https://api.logdna.co/v1/config/presetalert\": dial tcp: lookup api.logdna.co"),
+				ExpectError: regexp.MustCompile("Error: creating logdna_alert.*: error during HTTP request: Post \"https://api.logdna.co/v1/config/presetalert\": dial tcp: lookup api.logdna.co"),
 			},
 		},
 	})
 }
 
-func TestAlert_ErrorResourceName(t *testing.T) {
-	args := cloneDefaults(chnlDefaults["alert"])
+func TestAlert_DefaultName(t *testing.T) {
+	args := cloneDefaults(rsDefaults["alert"])
 	args["name"] = ""
+	chArgs := map[string]map[string]string{"email": cloneDefaults(chnlDefaults["email"])}
+	cfg := fmtTestConfigResource("alert", "new", globalPcArgs, args, chArgs, nilLst)
 
 	resource.Test(t, resource.TestCase{
 		Providers: testAccProviders,
 		Steps: []resource.TestStep{
 			{
-				Config:      fmtTestConfigResource("alert", "new", globalPcArgs, args, nilOpt, nilLst),
-				ExpectError: regexp.MustCompile("The argument \"name\" is required, but no definition was found."),
+				Config: cfg,
+				Check: resource.ComposeTestCheckFunc(
+					testResourceExists("alert", "new"),
+					resource.TestMatchResourceAttr("logdna_alert.new", "name", regexp.MustCompile(`^terraform-email-alert-\d+$`)),
+				),
+			},
+			{
+				Config:   cfg,
+				PlanOnly: true,
 			},
 		},
 	})
@@ -206,7 +226,7 @@ func TestAlert_Basic(t *testing.T) {
 					testResourceExists("alert", "new"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "name", "test"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.#", "1"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.0.%", "7"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.0.%", "11"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.0.emails.#", "1"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.0.emails.0", "test@logdna.com"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.0.immediate", "false"),
@@ -270,8 +290,8 @@ func TestAlert_BulkChannels(t *testing.T) {
 					testResourceExists("alert", "new"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "name", "test"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.#", "2"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.0.%", "7"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.1.%", "7"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.0.%", "11"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.1.%", "11"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.#", "0"),
@@ -283,8 +303,8 @@ func TestAlert_BulkChannels(t *testing.T) {
 					testResourceExists("alert", "new"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "name", "test"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.#", "2"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.0.%", "6"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.1.%", "6"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.0.%", "10"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.1.%", "10"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.#", "0"),
@@ -296,8 +316,8 @@ func TestAlert_BulkChannels(t *testing.T) {
 					testResourceExists("alert", "new"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "name", "test"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.#", "2"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.0.%", "6"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.1.%", "6"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.0.%", "10"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.1.%", "10"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.#", "0"),
@@ -309,8 +329,8 @@ func TestAlert_BulkChannels(t *testing.T) {
 					testResourceExists("alert", "new"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "name", "test"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.#", "2"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.0.%", "9"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.1.%", "9"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.0.%", "15"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.1.%", "15"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.#", "0"),
@@ -346,7 +366,7 @@ func TestAlert_MultipleChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.0.triggerinterval", "15m"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "email_channel.0.triggerlimit", "15"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.#", "1"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.0.%", "6"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.0.%", "10"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.0.immediate", "false"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.0.key", "Your PagerDuty API key goes here"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.0.operator", "presence"),
@@ -354,7 +374,7 @@ func TestAlert_MultipleChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.0.triggerinterval", "15m"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "pagerduty_channel.0.triggerlimit", "15"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.#", "1"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.0.%", "6"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.0.%", "10"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.0.immediate", "false"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.0.operator", "absence"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.0.terminal", "true"),
@@ -362,7 +382,7 @@ func TestAlert_MultipleChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.0.triggerlimit", "15"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "slack_channel.0.url", "https://hooks.slack.com/services/identifier/secret"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.#", "1"),
-					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.0.%", "9"),
+					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.0.%", "15"),
 					// The JSON will have newlines per our API which uses JSON.stringify(obj, null, 2) as the value
 					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.0.bodytemplate", "{\n  \"fields\": {\n    \"description\": \"{{ matches }} matches found for {{ name }}\",\n    \"issuetype\": {\n      \"name\": \"Bug\"\n    },\n    \"project\": {\n      \"key\": \"test\"\n    },\n    \"summary\": \"Alert from {{ name }}\"\n  }\n}"),
 					resource.TestCheckResourceAttr("logdna_alert.new", "webhook_channel.0.headers.%", "2"),
@@ -385,3 +405,135 @@ func TestAlert_MultipleChannels(t *testing.T) {
 		},
 	})
 }
+
+func TestAlert_GeneratedName(t *testing.T) {
+	assert := assert.New(t)
+	var posted alertRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			assert.Nil(json.NewDecoder(r.Body).Decode(&posted), "No errors")
+		}
+		assert.Nil(json.NewEncoder(w).Encode(alertResponse{
+			Name:     posted.Name,
+			PresetID: "abc",
+			Channels: []channelResponse{{Integration: SLACK, URL: "https://hooks.slack.com/x", Operator: "presence", TriggerLimit: 15}},
+		}), "No errors")
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceAlert()
+	cfg := map[string]interface{}{
+		"slack_channel": []interface{}{
+			map[string]interface{}{"url": "https://hooks.slack.com/x", "triggerlimit": 15},
+		},
+	}
+	d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+
+	diags := resourceAlertCreate(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("terraform-slack-alert-fb22fa8e", posted.Name, "A default name is sent")
+	assert.Equal(posted.Name, d.Get("name"), "The default name is stored")
+
+	diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	assert.True(diff.Empty(), "The generated name does not churn: %v", diff)
+}
+
+func TestAlert_defaultAlertName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("terraform-alert-25cbfc4f", defaultAlertName(nil), "No channels")
+	channels := []channelRequest{
+		{Integration: EMAIL},
+		{Integration: EMAIL},
+		{Integration: PAGERDUTY},
+	}
+	assert.Equal("terraform-email-pagerduty-alert-707d09b5", defaultAlertName(channels), "Integrations are listed once, in order")
+	assert.Equal(defaultAlertName(channels), defaultAlertName(channels), "The name is deterministic")
+
+	channels[2].Key = "other"
+	assert.NotEqual("terraform-email-pagerduty-alert-707d09b5", defaultAlertName(channels), "Alerts with other settings do not collide")
+}
+
+func TestAlert_GeneratedNameIsNotPlanned(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"slack_channel": []interface{}{
+			map[string]interface{}{"url": "https://hooks.slack.com/x", "triggerlimit": 15},
+		},
+	})
+	_, err := resourceAlert().Diff(context.Background(), nil, cfg, nil)
+	assert.Nil(err, "No errors")
+	assert.Contains(buf.String(), `[DEBUG] Planned request body for logdna_alert "": `, "Preview is logged")
+	assert.NotContains(buf.String(), "terraform-slack-alert", "The name is only generated on create")
+}
+
+func TestAlert_ReadDeletedOutOfBand(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	d := schema.TestResourceDataRaw(t, resourceAlert().Schema, map[string]interface{}{"name": "test"})
+	d.SetId("abc")
+
+	diags := resourceAlertRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("", d.Id(), "The alert is removed from the state")
+}
+
+func TestAlert_UpdateKeepsPresetID(t *testing.T) {
+	assert := assert.New(t)
+
+	var requests []string
+	stored := alertRequest{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "POST" || r.Method == "PUT" {
+			stored = alertRequest{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&stored), "No errors")
+		}
+		channels, _ := json.Marshal(stored.Channels)
+		_, _ = w.Write([]byte(`{"presetid": "p1", "name": "` + stored.Name + `", "channels": ` + string(channels) + `}`))
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceAlert()
+	channel := func(url string) map[string]interface{} {
+		return map[string]interface{}{"url": url, "triggerlimit": 15}
+	}
+	cfg := map[string]interface{}{
+		"name":          "test",
+		"slack_channel": []interface{}{channel("https://hooks.slack.com/first")},
+	}
+	d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+	diags := resourceAlertCreate(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("p1", d.Id(), "The preset ID is stored")
+
+	// Every argument of an alert is updated in place
+	cfg = map[string]interface{}{
+		"name":          "renamed",
+		"slack_channel": []interface{}{channel("https://hooks.slack.com/second")},
+		"email_channel": []interface{}{map[string]interface{}{"emails": []interface{}{"a@logdna.com"}, "triggerlimit": 15}},
+	}
+	diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	assert.False(diff.RequiresNew(), "The alert is not recreated, which would detach its views")
+
+	requests = nil
+	state, diags := rs.Apply(context.Background(), d.State(), diff, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal([]string{"PUT /v1/config/presetalert/p1", "GET /v1/config/presetalert/p1"}, requests, "The preset is updated in place")
+	assert.Equal("p1", state.ID, "The preset ID referenced by the views is kept")
+	assert.Equal("renamed", state.Attributes["name"], "The update is read back")
+}
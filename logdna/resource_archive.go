@@ -4,13 +4,59 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 const archiveConfigID = "archive"
 
+// archiveSensitiveFields are never compared after a write since the API may
+// mask them in its responses
+var archiveSensitiveFields = map[string]bool{
+	"apikey":     true,
+	"accountkey": true,
+	"accesskey":  true,
+	"secretkey":  true,
+	"password":   true,
+}
+
+// archiveCredentials names the credentials required by each integration; s3
+// and gcs are authorized on the bucket side and take none
+var archiveCredentials = map[string]map[string]string{
+	"ibm":    {"apikey": "IBM API key", "resourceinstanceid": "IBM resource instance ID"},
+	"azblob": {"accountname": "Azure storage account name", "accountkey": "Azure storage account key"},
+	"dos":    {"accesskey": "DigitalOcean Spaces access key", "secretkey": "DigitalOcean Spaces secret key"},
+	"swift":  {"username": "Swift username", "password": "Swift password"},
+}
+
+// validateArchiveCredential rejects empty credentials at plan time, since the
+// API accepts them and stores an archive configuration that cannot upload
+func validateArchiveCredential(integration string, field string) schema.SchemaValidateFunc {
+	name := archiveCredentials[integration][field]
+	return func(val interface{}, key string) (warns []string, errs []error) {
+		if strings.TrimSpace(val.(string)) == "" {
+			errs = append(errs, fmt.Errorf("%q: the %s must not be empty", key, name))
+		}
+		return
+	}
+}
+
+var (
+	archiveFormats      = []string{"json", "jsonl"}
+	archiveCompressions = []string{"gzip", "none"}
+)
+
+// archiveFormat applies to every integration; empty values keep the default
+// of the API
+type archiveFormat struct {
+	Format      string `json:"format,omitempty"`
+	Compression string `json:"compression,omitempty"`
+}
+
 type ibmConfig struct {
 	Bucket             string `json:"bucket"`
 	Endpoint           string `json:"endpoint"`
@@ -56,6 +102,10 @@ func generateArchiveConfig(d *schema.ResourceData) (interface{}, error) {
 		return nil, err
 	}
 	config := configRaw[0].(map[string]interface{})
+	format := archiveFormat{
+		Format:      d.Get("format").(string),
+		Compression: d.Get("compression").(string),
+	}
 
 	if integration == "ibm" {
 		ibm := ibmConfig{
@@ -66,16 +116,18 @@ func generateArchiveConfig(d *schema.ResourceData) (interface{}, error) {
 		}
 		return struct {
 			Integration string `json:"integration"`
+			archiveFormat
 			ibmConfig
-		}{integration, ibm}, nil
+		}{integration, format, ibm}, nil
 	} else if integration == "s3" {
 		s3 := s3Config{
 			Bucket: config["bucket"].(string),
 		}
 		return struct {
 			Integration string `json:"integration"`
+			archiveFormat
 			s3Config
-		}{integration, s3}, nil
+		}{integration, format, s3}, nil
 	} else if integration == "azblob" {
 		azblob := azblobConfig{
 			AccountName: config["accountname"].(string),
@@ -83,8 +135,9 @@ func generateArchiveConfig(d *schema.ResourceData) (interface{}, error) {
 		}
 		return struct {
 			Integration string `json:"integration"`
+			archiveFormat
 			azblobConfig
-		}{integration, azblob}, nil
+		}{integration, format, azblob}, nil
 	} else if integration == "gcs" {
 		gcs := gcsConfig{
 			Bucket:    config["bucket"].(string),
@@ -92,8 +145,9 @@ func generateArchiveConfig(d *schema.ResourceData) (interface{}, error) {
 		}
 		return struct {
 			Integration string `json:"integration"`
+			archiveFormat
 			gcsConfig
-		}{integration, gcs}, nil
+		}{integration, format, gcs}, nil
 	} else if integration == "dos" {
 		dos := dosConfig{
 			Space:     config["space"].(string),
@@ -103,8 +157,9 @@ func generateArchiveConfig(d *schema.ResourceData) (interface{}, error) {
 		}
 		return struct {
 			Integration string `json:"integration"`
+			archiveFormat
 			dosConfig
-		}{integration, dos}, nil
+		}{integration, format, dos}, nil
 	} else {
 		swift := swiftConfig{
 			AuthURL:    config["authurl"].(string),
@@ -115,14 +170,17 @@ func generateArchiveConfig(d *schema.ResourceData) (interface{}, error) {
 		}
 		return struct {
 			Integration string `json:"integration"`
+			archiveFormat
 			swiftConfig
-		}{integration, swift}, nil
+		}{integration, format, swift}, nil
 	}
 }
 
 func setArchiveConfig(cn archiveResponse, d *schema.ResourceData, diags diag.Diagnostics) {
 	integration := cn.Integration
 	appendError(d.Set("integration", integration), &diags)
+	appendError(d.Set("format", cn.Format), &diags)
+	appendError(d.Set("compression", cn.Compression), &diags)
 
 	switch integration {
 	case "ibm":
@@ -164,35 +222,80 @@ func setArchiveConfig(cn archiveResponse, d *schema.ResourceData, diags diag.Dia
 	}
 }
 
+// archiveVerifiableFields returns the integration and the non-sensitive fields
+// of its config block as they currently are in d
+func archiveVerifiableFields(d *schema.ResourceData) map[string]interface{} {
+	integration := d.Get("integration").(string)
+	fields := map[string]interface{}{"integration": integration}
+	for _, k := range []string{"format", "compression"} {
+		if v := d.Get(k).(string); v != "" {
+			fields[k] = v
+		}
+	}
+
+	configRaw := d.Get(fmt.Sprintf("%s_config", integration)).([]interface{})
+	if len(configRaw) == 0 || configRaw[0] == nil {
+		return fields
+	}
+	for k, v := range configRaw[0].(map[string]interface{}) {
+		if !archiveSensitiveFields[k] {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// verifyArchiveConfig warns about every field that was stored differently than
+// it was sent, since the API may normalize or drop values without an error
+func verifyArchiveConfig(sent map[string]interface{}, stored map[string]interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	keys := make([]string, 0, len(sent))
+	for k := range sent {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if sent[k] != stored[k] {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("The archive configuration field %q was not stored as sent", k),
+				Detail:   fmt.Sprintf("Sent %v but the remote archive configuration contains %v", sent[k], stored[k]),
+			})
+		}
+	}
+	return diags
+}
+
 func resourceArchiveConfigCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	pc := m.(*providerConfig)
 	c, err := generateArchiveConfig(d)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_archive", d, err))
 	}
 
 	req := newRequestConfig(
 		pc,
 		"POST",
-		"/v1/config/archiving",
+		pc.endpoint("archive.create"),
 		c,
 	)
 
-	body, err := req.MakeRequest()
+	_, err = Do[archiveResponse](ctx, req)
 	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	cn := archiveResponse{}
-	err = json.Unmarshal(body, &cn)
-	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_archive", d, err))
 	}
 
 	d.SetId(archiveConfigID)
 
-	return resourceArchiveConfigRead(ctx, d, m)
+	sent := archiveVerifiableFields(d)
+	diags := resourceArchiveConfigRead(ctx, d, m)
+	if diags.HasError() {
+		return diags
+	}
+	return append(diags, verifyArchiveConfig(sent, archiveVerifiableFields(d))...)
 }
 
 func resourceArchiveConfigRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -202,17 +305,17 @@ func resourceArchiveConfigRead(ctx context.Context, d *schema.ResourceData, m in
 	req := newRequestConfig(
 		pc,
 		"GET",
-		"/v1/config/archiving",
+		pc.endpoint("archive.read"),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot read the remote archive resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_archive", d, err).Error(),
 		})
 		return diags
 	}
@@ -223,7 +326,7 @@ func resourceArchiveConfigRead(ctx context.Context, d *schema.ResourceData, m in
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot unmarshal response from the remote archive resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_archive", d, err).Error(),
 		})
 		return diags
 	}
@@ -237,28 +340,27 @@ func resourceArchiveConfigUpdate(ctx context.Context, d *schema.ResourceData, m
 	c, err := generateArchiveConfig(d)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("updating", "logdna_archive", d, err))
 	}
 
 	req := newRequestConfig(
 		pc,
 		"PUT",
-		"/v1/config/archiving",
+		pc.endpoint("archive.update"),
 		c,
 	)
 
-	body, err := req.MakeRequest()
+	_, err = Do[archiveResponse](ctx, req)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("updating", "logdna_archive", d, err))
 	}
 
-	cn := archiveResponse{}
-	err = json.Unmarshal(body, &cn)
-	if err != nil {
-		return diag.FromErr(err)
+	sent := archiveVerifiableFields(d)
+	diags := resourceArchiveConfigRead(ctx, d, m)
+	if diags.HasError() {
+		return diags
 	}
-
-	return resourceArchiveConfigRead(ctx, d, m)
+	return append(diags, verifyArchiveConfig(sent, archiveVerifiableFields(d))...)
 }
 
 func resourceArchiveConfigDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -266,13 +368,13 @@ func resourceArchiveConfigDelete(ctx context.Context, d *schema.ResourceData, m
 	req := newRequestConfig(
 		pc,
 		"DELETE",
-		"/v1/config/archiving",
+		pc.endpoint("archive.delete"),
 		nil,
 	)
 
-	_, err := req.MakeRequest()
+	_, err := req.MakeRequestWithContext(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return pc.deleteError(d, "logdna_archive", err)
 	}
 
 	d.SetId("")
@@ -309,6 +411,18 @@ func resourceArchiveConfig() *schema.Resource {
 					return
 				},
 			},
+			"format": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice(archiveFormats, false),
+			},
+			"compression": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice(archiveCompressions, false),
+			},
 			"ibm_config": {
 				Type:     schema.TypeList,
 				Optional: true,
@@ -324,12 +438,14 @@ func resourceArchiveConfig() *schema.Resource {
 							Required: true,
 						},
 						"apikey": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArchiveCredential("ibm", "apikey"),
 						},
 						"resourceinstanceid": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArchiveCredential("ibm", "resourceinstanceid"),
 						},
 					},
 				},
@@ -354,12 +470,14 @@ func resourceArchiveConfig() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"accountname": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArchiveCredential("azblob", "accountname"),
 						},
 						"accountkey": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArchiveCredential("azblob", "accountkey"),
 						},
 					},
 				},
@@ -396,12 +514,14 @@ func resourceArchiveConfig() *schema.Resource {
 							Required: true,
 						},
 						"accesskey": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArchiveCredential("dos", "accesskey"),
 						},
 						"secretkey": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArchiveCredential("dos", "secretkey"),
 						},
 					},
 				},
@@ -421,12 +541,14 @@ func resourceArchiveConfig() *schema.Resource {
 							Optional: true,
 						},
 						"username": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArchiveCredential("swift", "username"),
 						},
 						"password": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArchiveCredential("swift", "password"),
 						},
 						"tenantname": {
 							Type:     schema.TypeString,
@@ -1,12 +1,23 @@
 package logdna
 
 import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
 )
 
 var s3Bucket = os.Getenv("S3_BUCKET")
@@ -43,7 +54,7 @@ func TestArchiveConfig_expectInvalidURLError(t *testing.T) {
 						bucket = "%s"
 					}
 				`, s3Bucket), "http://api.logdna.co"),
-				ExpectError: regexp.MustCompile("Error: error during HTTP request: Post \"http://api.logdna.co/v1/config/archiving\": dial tcp: lookup api.logdna.co"),
+				ExpectError: regexp.MustCompile("Error: creating logdna_archive.*: error during HTTP request: Post \"http://api.logdna.co/v1/config/archiving\": dial tcp: lookup api.logdna.co"),
 			},
 		},
 	})
@@ -138,3 +149,214 @@ func testArchiveConfig(fields string, url string) string {
 		}
 	`, serviceKey, uc, fields)
 }
+
+func TestArchiveConfig_VerifyAfterWrite(t *testing.T) {
+	assert := assert.New(t)
+
+	stored := `{
+		"integration": "ibm",
+		"bucket": "logs",
+		"endpoint": "%s",
+		"apikey": "********",
+		"resourceinstanceid": "crn:1"
+	}`
+	cfg := map[string]interface{}{
+		"integration": "ibm",
+		"ibm_config": []interface{}{
+			map[string]interface{}{
+				"bucket":             "logs",
+				"endpoint":           "s3.us-south.cloud-object-storage.appdomain.cloud",
+				"apikey":             "secret",
+				"resourceinstanceid": "crn:1",
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		endpoint string
+		warnings int
+	}{
+		{"No warning when the config is stored as sent", "s3.us-south.cloud-object-storage.appdomain.cloud", 0},
+		{"Warns when the server modified a field", "s3.private.us-south.cloud-object-storage.appdomain.cloud", 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, stored, tc.endpoint)
+			}))
+			defer ts.Close()
+
+			pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+			d := schema.TestResourceDataRaw(t, resourceArchiveConfig().Schema, cfg)
+
+			diags := resourceArchiveConfigCreate(context.Background(), d, &pc)
+			assert.False(diags.HasError(), "No errors")
+			assert.Len(diags, tc.warnings, "Warnings")
+			if tc.warnings > 0 {
+				assert.Equal(diag.Warning, diags[0].Severity, "The level is Warning")
+				assert.Equal(`The archive configuration field "endpoint" was not stored as sent`, diags[0].Summary, "Summary")
+				assert.Contains(diags[0].Detail, tc.endpoint, "Detail has the stored value")
+			}
+		})
+	}
+}
+
+func TestArchiveConfig_FormatAndCompression(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, format := range archiveFormats {
+		for _, compression := range archiveCompressions {
+			t.Run(fmt.Sprintf("%s with %s", format, compression), func(t *testing.T) {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Method == "POST" {
+						sent := archiveResponse{}
+						assert.Nil(json.NewDecoder(r.Body).Decode(&sent), "No errors")
+						assert.Equal(format, sent.Format, "format is sent")
+						assert.Equal(compression, sent.Compression, "compression is sent")
+					}
+					fmt.Fprintf(
+						w,
+						`{"integration":"s3","bucket":"logs","format":%q,"compression":%q}`,
+						format,
+						compression,
+					)
+				}))
+				defer ts.Close()
+
+				pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+				d := schema.TestResourceDataRaw(t, resourceArchiveConfig().Schema, map[string]interface{}{
+					"integration": "s3",
+					"format":      format,
+					"compression": compression,
+					"s3_config":   []interface{}{map[string]interface{}{"bucket": "logs"}},
+				})
+
+				diags := resourceArchiveConfigCreate(context.Background(), d, &pc)
+				assert.False(diags.HasError(), "No errors")
+				assert.Empty(diags, "Stored as sent")
+				assert.Equal(format, d.Get("format"), "format is read back")
+				assert.Equal(compression, d.Get("compression"), "compression is read back")
+			})
+		}
+	}
+
+	t.Run("Keeps the API default when omitted", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				body, _ := ioutil.ReadAll(r.Body)
+				assert.NotContains(string(body), "format", "format is not sent")
+				assert.NotContains(string(body), "compression", "compression is not sent")
+			}
+			fmt.Fprint(w, `{"integration":"s3","bucket":"logs","format":"jsonl","compression":"gzip"}`)
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := schema.TestResourceDataRaw(t, resourceArchiveConfig().Schema, map[string]interface{}{
+			"integration": "s3",
+			"s3_config":   []interface{}{map[string]interface{}{"bucket": "logs"}},
+		})
+
+		diags := resourceArchiveConfigCreate(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Empty(diags, "No warnings")
+		assert.Equal("jsonl", d.Get("format"), "Default format is stored")
+		assert.Equal("gzip", d.Get("compression"), "Default compression is stored")
+	})
+
+	t.Run("Rejects unsupported values", func(t *testing.T) {
+		s := resourceArchiveConfig().Schema
+		_, errs := s["format"].ValidateFunc("csv", "format")
+		assert.Len(errs, 1, "Unsupported format")
+		_, errs = s["compression"].ValidateFunc("zip", "compression")
+		assert.Len(errs, 1, "Unsupported compression")
+	})
+}
+
+func TestArchiveConfig_EmptyCredentials(t *testing.T) {
+	assert := assert.New(t)
+	rs := resourceArchiveConfig()
+
+	// fullConfig fills every required field of the integration's config block
+	fullConfig := func(integration string) map[string]interface{} {
+		block := rs.Schema[integration+"_config"].Elem.(*schema.Resource).Schema
+		fields := map[string]interface{}{}
+		for k, s := range block {
+			if s.Required {
+				fields[k] = "value"
+			}
+		}
+		return fields
+	}
+	validate := func(integration string, fields map[string]interface{}) diag.Diagnostics {
+		return rs.Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+			"integration":           integration,
+			integration + "_config": []interface{}{fields},
+		}))
+	}
+
+	for _, integration := range []string{"ibm", "s3", "azblob", "gcs", "dos", "swift"} {
+		t.Run(integration, func(t *testing.T) {
+			diags := validate(integration, fullConfig(integration))
+			assert.False(diags.HasError(), "No errors with every credential set: %v", diags)
+
+			for field, name := range archiveCredentials[integration] {
+				for _, empty := range []string{"", "  "} {
+					fields := fullConfig(integration)
+					fields[field] = empty
+
+					diags := validate(integration, fields)
+					assert.True(diags.HasError(), "Expected an error for an empty %s", field)
+					assert.Len(diags, 1, "Only the empty credential is reported")
+					assert.Contains(diags[0].Summary, fmt.Sprintf("the %s must not be empty", name), "The credential is named")
+				}
+			}
+		})
+	}
+
+	assert.Empty(archiveCredentials["s3"], "s3 takes no credentials")
+	assert.Empty(archiveCredentials["gcs"], "gcs takes no credentials")
+}
+
+func TestArchiveConfig_GzippedResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Compressed whether or not the client asked for it, like some gateways
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		fmt.Fprint(zw, `{"integration":"s3","bucket":"logs","format":"jsonl","compression":"gzip"}`)
+		zw.Close()
+	}))
+	defer ts.Close()
+
+	for name, client := range map[string]*http.Client{
+		"Negotiated by the transport": {Timeout: 15 * time.Second},
+		"Sent unasked":                {Timeout: 15 * time.Second, Transport: &http.Transport{DisableCompression: true}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pc := providerConfig{baseURL: ts.URL, httpClient: client}
+			d := schema.TestResourceDataRaw(t, resourceArchiveConfig().Schema, map[string]interface{}{})
+			d.SetId(archiveConfigID)
+
+			diags := resourceArchiveConfigRead(context.Background(), d, &pc)
+			assert.False(diags.HasError(), "No errors: %v", diags)
+			assert.Equal("s3", d.Get("integration"), "integration is decoded")
+			assert.Equal("logs", d.Get("s3_config.0.bucket"), "bucket is decoded")
+		})
+	}
+
+	t.Run("Fails on a corrupted body", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			fmt.Fprint(w, `{"integration":"s3"}`)
+		}))
+		defer ts.Close()
+
+		client := &http.Client{Timeout: 15 * time.Second, Transport: &http.Transport{DisableCompression: true}}
+		pc := providerConfig{baseURL: ts.URL, httpClient: client}
+		_, err := newRequestConfig(&pc, "GET", "/v1/config/archiving", nil).MakeRequest()
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "error parsing HTTP response: gzip: invalid header", "The decompression error is reported")
+	})
+}
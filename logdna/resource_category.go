@@ -9,6 +9,7 @@ import (
 
   "github.com/hashicorp/terraform-plugin-sdk/v2/diag"
   "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+  "github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceCategoryCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -26,22 +27,22 @@ func resourceCategoryCreate(ctx context.Context, d *schema.ResourceData, m inter
   req := newRequestConfig(
     pc,
     "POST",
-    fmt.Sprintf("/v1/config/categories/%s", categoryType),
+    pc.endpoint("category.create", categoryType),
     category,
   )
 
-  body, err := req.MakeRequest()
+  body, err := req.MakeRequestWithContext(ctx)
   log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
 
   if err != nil {
-    return diag.FromErr(err)
+    return diag.FromErr(operationError("creating", "logdna_category", d, err))
   }
 
   createdCategory := categoryResponse{}
   err = json.Unmarshal(body, &createdCategory)
 
   if err != nil {
-    return diag.FromErr(err)
+    return diag.FromErr(operationError("creating", "logdna_category", d, err))
   }
 
   log.Printf("[DEBUG] After %s categories, the created category is %+v", req.method, createdCategory)
@@ -60,7 +61,7 @@ func resourceCategoryUpdate(ctx context.Context, d *schema.ResourceData, m inter
   categoryType, categoryId, err := parseCategoryId(d.Id())
 
   if err != nil {
-    return diag.FromErr(err)
+    return diag.FromErr(operationError("updating", "logdna_category", d, err))
   }
 
   category := categoryRequest{}
@@ -72,15 +73,15 @@ func resourceCategoryUpdate(ctx context.Context, d *schema.ResourceData, m inter
   req := newRequestConfig(
     pc,
     "PUT",
-    fmt.Sprintf("/v1/config/categories/%s/%s", categoryType, categoryId),
+    pc.endpoint("category.update", categoryType, categoryId),
     category,
   )
 
-  body, err := req.MakeRequest()
+  body, err := req.MakeRequestWithContext(ctx)
   log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
 
   if err != nil {
-    return diag.FromErr(err)
+    return diag.FromErr(operationError("updating", "logdna_category", d, err))
   }
 
   log.Printf("[DEBUG] %s %s SUCCESS. Remote resource updated.", req.method, req.apiURL)
@@ -96,24 +97,24 @@ func resourceCategoryRead(ctx context.Context, d *schema.ResourceData, m interfa
   categoryType, categoryId, err := parseCategoryId(d.Id())
 
   if err != nil {
-    return diag.FromErr(err)
+    return diag.FromErr(operationError("reading", "logdna_category", d, err))
   }
 
   req := newRequestConfig(
     pc,
     "GET",
-    fmt.Sprintf("/v1/config/categories/%s/%s", categoryType, categoryId),
+    pc.endpoint("category.read", categoryType, categoryId),
     nil,
   )
 
-  body, err := req.MakeRequest()
+  body, err := req.MakeRequestWithContext(ctx)
 
   log.Printf("[DEBUG] GET categories raw response body %s\n", body)
   if err != nil {
     diags = append(diags, diag.Diagnostic{
       Severity: diag.Error,
       Summary:  "Cannot read the remote categories resource",
-      Detail:   err.Error(),
+      Detail:   operationError("reading", "logdna_category", d, err).Error(),
     })
     return diags
   }
@@ -124,7 +125,7 @@ func resourceCategoryRead(ctx context.Context, d *schema.ResourceData, m interfa
     diags = append(diags, diag.Diagnostic{
       Severity: diag.Error,
       Summary:  "Cannot unmarshal response from the remote categories resource",
-      Detail:   err.Error(),
+      Detail:   operationError("reading", "logdna_category", d, err).Error(),
     })
     return diags
   }
@@ -148,15 +149,15 @@ func resourceCategoryDelete(ctx context.Context, d *schema.ResourceData, m inter
   req := newRequestConfig(
     pc,
     "DELETE",
-    fmt.Sprintf("/v1/config/categories/%s/%s", categoryType, categoryId),
+    pc.endpoint("category.delete", categoryType, categoryId),
     nil,
   )
 
-  body, err := req.MakeRequest()
+  body, err := req.MakeRequestWithContext(ctx)
   log.Printf("[DEBUG] %s %s presetalert %s", req.method, req.apiURL, body)
 
   if err != nil {
-    return diag.FromErr(err)
+    return pc.deleteError(d, "logdna_category", err)
   }
   d.SetId("")
   return nil
@@ -203,9 +204,10 @@ func resourceCategory() *schema.Resource {
       // NOTE Type is added to the schema but it's not used in a request body
       //      as the type is used just as a part of a url
       "type": {
-        Type:     schema.TypeString,
-        Optional: true,
-        Default:  "views",
+        Type:         schema.TypeString,
+        Optional:     true,
+        Default:      "views",
+        ValidateFunc: validation.StringInSlice(categoryTypes, false),
       },
     },
   }
@@ -20,7 +20,7 @@ func TestCategory_ErrorProviderUrl(t *testing.T) {
     Steps: []resource.TestStep{
       {
         Config: fmtTestConfigResource("category", "new", pcArgs, catArgs, nilOpt, nilLst),
-        ExpectError: regexp.MustCompile("Error: error during HTTP request: Post \"https://api.logdna.co/v1/config/categories/views\": dial tcp: lookup api.logdna.co"),
+        ExpectError: regexp.MustCompile("Error: creating logdna_category.*: error during HTTP request: Post \"https://api.logdna.co/v1/config/categories/views\": dial tcp: lookup api.logdna.co"),
       },
     },
   })
@@ -53,7 +53,7 @@ func TestCategory_ErrorResourceType(t *testing.T) {
     Steps: []resource.TestStep{
       {
         Config: fmtTestConfigResource("category", "new", globalPcArgs, catArgs, nilOpt, nilLst),
-        ExpectError: regexp.MustCompile("Error: POST .+?, status 400 NOT OK!"),
+        ExpectError: regexp.MustCompile("Error: creating logdna_category \"test-category\": POST .+?, status 400 NOT OK!"),
       },
     },
   })
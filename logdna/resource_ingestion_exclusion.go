@@ -3,7 +3,6 @@ package logdna
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -17,7 +16,7 @@ func resourceIngestionExclusionCreate(ctx context.Context, d *schema.ResourceDat
 	pc := m.(*providerConfig)
 	ex := exclusionRule{
 		Title:  d.Get("title").(string),
-		Active: d.Get("active").(bool),
+		Active: flexBool(d.Get("active").(bool)),
 		Apps:   listToStrings(d.Get("apps").([]interface{})),
 		Hosts:  listToStrings(d.Get("hosts").([]interface{})),
 		Query:  d.Get("query").(string),
@@ -26,24 +25,18 @@ func resourceIngestionExclusionCreate(ctx context.Context, d *schema.ResourceDat
 	req := newRequestConfig(
 		pc,
 		"POST",
-		baseIngestionExclusionUrl,
+		pc.endpoint("ingestion_exclusion.create"),
 		ex,
 	)
 
-	body, err := req.MakeRequest()
+	exn, err := Do[exclusionRule](ctx, req)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_ingestion_exclusion", d, err))
 	}
 
-	exn := exclusionRule{}
-	err = json.Unmarshal(body, &exn)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	d.SetId(exn.ID)
+	d.SetId(string(exn.ID))
 	appendError(d.Set("title", exn.Title), &diags)
-	appendError(d.Set("active", exn.Active), &diags)
+	appendError(d.Set("active", bool(exn.Active)), &diags)
 	appendError(d.Set("apps", exn.Apps), &diags)
 	appendError(d.Set("hosts", exn.Hosts), &diags)
 	appendError(d.Set("query", exn.Query), &diags)
@@ -58,16 +51,16 @@ func resourceIngestionExclusionRead(ctx context.Context, d *schema.ResourceData,
 	req := newRequestConfig(
 		pc,
 		"GET",
-		fmt.Sprintf("%s/%s", baseIngestionExclusionUrl, d.Id()),
+		pc.endpoint("ingestion_exclusion.read", d.Id()),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot read the remote ingestion exclusion resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_ingestion_exclusion", d, err).Error(),
 		})
 		return diags
 	}
@@ -78,13 +71,13 @@ func resourceIngestionExclusionRead(ctx context.Context, d *schema.ResourceData,
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot unmarshal response from the remote ingestion exclusion resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_ingestion_exclusion", d, err).Error(),
 		})
 		return diags
 	}
 
 	appendError(d.Set("title", ex.Title), &diags)
-	appendError(d.Set("active", ex.Active), &diags)
+	appendError(d.Set("active", bool(ex.Active)), &diags)
 	appendError(d.Set("apps", ex.Apps), &diags)
 	appendError(d.Set("hosts", ex.Hosts), &diags)
 	appendError(d.Set("query", ex.Query), &diags)
@@ -96,7 +89,7 @@ func resourceIngestionExclusionUpdate(ctx context.Context, d *schema.ResourceDat
 	pc := m.(*providerConfig)
 	ex := exclusionRule{
 		Title:  d.Get("title").(string),
-		Active: d.Get("active").(bool),
+		Active: flexBool(d.Get("active").(bool)),
 		Apps:   listToStrings(d.Get("apps").([]interface{})),
 		Hosts:  listToStrings(d.Get("hosts").([]interface{})),
 		Query:  d.Get("query").(string),
@@ -105,13 +98,13 @@ func resourceIngestionExclusionUpdate(ctx context.Context, d *schema.ResourceDat
 	req := newRequestConfig(
 		pc,
 		"PATCH",
-		fmt.Sprintf("%s/%s", baseIngestionExclusionUrl, d.Id()),
+		pc.endpoint("ingestion_exclusion.update", d.Id()),
 		ex,
 	)
 
-	_, err := req.MakeRequest()
+	_, err := req.MakeRequestWithContext(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("updating", "logdna_ingestion_exclusion", d, err))
 	}
 
 	return resourceIngestionExclusionRead(ctx, d, m)
@@ -122,13 +115,13 @@ func resourceIngestionExclusionDelete(ctx context.Context, d *schema.ResourceDat
 	req := newRequestConfig(
 		pc,
 		"DELETE",
-		fmt.Sprintf("%s/%s", baseIngestionExclusionUrl, d.Id()),
+		pc.endpoint("ingestion_exclusion.delete", d.Id()),
 		nil,
 	)
 
-	_, err := req.MakeRequest()
+	_, err := req.MakeRequestWithContext(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return pc.deleteError(d, "logdna_ingestion_exclusion", err)
 	}
 
 	d.SetId("")
@@ -17,7 +17,7 @@ func TestIngestionExclusion_expectInvalidURLError(t *testing.T) {
 					title = "test-title"
 					query = "foo"
 				`, "http://api.logdna.co"),
-				ExpectError: regexp.MustCompile("Error: error during HTTP request: Post \"http://api.logdna.co/v1/config/ingestion/exclusions\": dial tcp: lookup api.logdna.co"),
+				ExpectError: regexp.MustCompile("Error: creating logdna_ingestion_exclusion.*: error during HTTP request: Post \"http://api.logdna.co/v1/config/ingestion/exclusions\": dial tcp: lookup api.logdna.co"),
 			},
 		},
 	})
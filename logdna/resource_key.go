@@ -25,29 +25,61 @@ func resourceKeyCreate(ctx context.Context, d *schema.ResourceData, m interface{
 	req := newRequestConfig(
 		pc,
 		"POST",
-		fmt.Sprintf("/v1/config/keys?type=%s", keyType),
+		pc.endpoint("key.create", keyType),
 		key,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
 
+	if isConflictErr(err) && d.Get("adopt_existing").(bool) {
+		existing, adoptErr := findExistingKey(ctx, pc, keyType, key.Name)
+		if adoptErr != nil {
+			return diag.FromErr(operationError("creating", "logdna_key", d, fmt.Errorf("%w, and it cannot be adopted: %s", err, adoptErr)))
+		}
+		log.Printf("[WARN] A %s key named %q already exists, adopting key %s", keyType, key.Name, existing.KeyID)
+		d.SetId(string(existing.KeyID))
+		return resourceKeyRead(ctx, d, m)
+	}
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_key", d, err))
 	}
 
 	createdKey := keyResponse{}
 	err = json.Unmarshal(body, &createdKey)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_key", d, err))
 	}
 	log.Printf("[DEBUG] After %s key, the created key is %+v", req.method, createdKey)
 
-	d.SetId(createdKey.KeyID)
+	d.SetId(string(createdKey.KeyID))
 
 	return resourceKeyRead(ctx, d, m)
 }
 
+// findExistingKey returns the only key of keyType with the given name, to be
+// adopted when its creation conflicts. An empty name matches any key.
+func findExistingKey(ctx context.Context, pc *providerConfig, keyType string, name string) (keyResponse, error) {
+	keys := []keyResponse{}
+	if err := newRequestConfig(pc, "GET", pc.endpoint("key.list", keyType), nil).MakePaginatedRequest(ctx, &keys); err != nil {
+		return keyResponse{}, err
+	}
+
+	matches := []keyResponse{}
+	for _, k := range keys {
+		if k.Type == keyType && (name == "" || k.Name == name) {
+			matches = append(matches, k)
+		}
+	}
+	if len(matches) != 1 && name == "" {
+		return keyResponse{}, fmt.Errorf("%d %s keys exist, expected exactly one; set the name of the key to pick one", len(matches), keyType)
+	}
+	if len(matches) != 1 {
+		return keyResponse{}, fmt.Errorf("%d %s keys are named %q, expected exactly one", len(matches), keyType, name)
+	}
+	return matches[0], nil
+}
+
 func resourceKeyUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	pc := m.(*providerConfig)
@@ -61,15 +93,15 @@ func resourceKeyUpdate(ctx context.Context, d *schema.ResourceData, m interface{
 	req := newRequestConfig(
 		pc,
 		"PUT",
-		fmt.Sprintf("/v1/config/keys/%s", keyID),
+		pc.endpoint("key.update", keyID),
 		key,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("updating", "logdna_key", d, err))
 	}
 
 	log.Printf("[DEBUG] %s %s SUCCESS. Remote resource updated.", req.method, req.apiURL)
@@ -86,18 +118,18 @@ func resourceKeyRead(ctx context.Context, d *schema.ResourceData, m interface{})
 	req := newRequestConfig(
 		pc,
 		"GET",
-		fmt.Sprintf("/v1/config/keys/%s", keyID),
+		pc.endpoint("key.read", keyID),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 
 	log.Printf("[DEBUG] GET key raw response body %s\n", body)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot read the remote key resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_key", d, err).Error(),
 		})
 		return diags
 	}
@@ -108,7 +140,7 @@ func resourceKeyRead(ctx context.Context, d *schema.ResourceData, m interface{})
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot unmarshal response from the remote key resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_key", d, err).Error(),
 		})
 		return diags
 	}
@@ -117,7 +149,7 @@ func resourceKeyRead(ctx context.Context, d *schema.ResourceData, m interface{})
 	// Top level keys can be set directly
 	appendError(d.Set("type", key.Type), &diags)
 	appendError(d.Set("name", key.Name), &diags)
-	appendError(d.Set("id", key.KeyID), &diags)
+	appendError(d.Set("id", string(key.KeyID)), &diags)
 	appendError(d.Set("key", key.Key), &diags)
 	appendError(d.Set("created", key.Created), &diags)
 
@@ -131,15 +163,15 @@ func resourceKeyDelete(ctx context.Context, d *schema.ResourceData, m interface{
 	req := newRequestConfig(
 		pc,
 		"DELETE",
-		fmt.Sprintf("/v1/config/keys/%s", keyID),
+		pc.endpoint("key.delete", keyID),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	log.Printf("[DEBUG] %s %s key %s", req.method, req.apiURL, body)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return pc.deleteError(d, "logdna_key", err)
 	}
 
 	d.SetId("")
@@ -186,6 +218,11 @@ func resourceKey() *schema.Resource {
 				ForceNew: true,
 				Computed: true,
 			},
+			"adopt_existing": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 		},
 	}
 }
@@ -1,11 +1,18 @@
 package logdna
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestKey_ErrorResourceTypeUndefined(t *testing.T) {
@@ -123,3 +130,67 @@ func TestKey_Basic(t *testing.T) {
 		},
 	})
 }
+
+func TestKey_AdoptExisting(t *testing.T) {
+	assert := assert.New(t)
+
+	keys := []keyResponse{
+		{KeyID: "k1", Key: "secret-1", Name: "ci", Type: "service"},
+		{KeyID: "k2", Key: "secret-2", Name: "agents", Type: "ingestion"},
+		{KeyID: "k3", Key: "secret-3", Name: "ops", Type: "service"},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST":
+			w.WriteHeader(409)
+			_, err := w.Write([]byte(`{"error": "Key already exists"}`))
+			assert.Nil(err, "No errors")
+		case r.URL.Path == "/v1/config/keys":
+			assert.Equal("service", r.URL.Query().Get("type"), "The keys of the type are listed")
+			// ops is on the second page
+			page := map[string]interface{}{"data": keys[:2], "nextPage": "2"}
+			if r.URL.Query().Get("offset") == "2" {
+				page = map[string]interface{}{"data": keys[2:], "nextPage": nil}
+			}
+			assert.Nil(json.NewEncoder(w).Encode(page), "No errors")
+		default:
+			for _, k := range keys {
+				if r.URL.Path == "/v1/config/keys/"+string(k.KeyID) {
+					assert.Nil(json.NewEncoder(w).Encode(k), "No errors")
+					return
+				}
+			}
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	create := func(raw map[string]interface{}) (*schema.ResourceData, []string) {
+		d := schema.TestResourceDataRaw(t, resourceKey().Schema, raw)
+		diags := resourceKeyCreate(context.Background(), d, &pc)
+		errs := []string{}
+		for _, diag := range diags {
+			errs = append(errs, diag.Summary)
+		}
+		return d, errs
+	}
+
+	d, errs := create(map[string]interface{}{"type": "service", "name": "ops"})
+	assert.Len(errs, 1, "The conflict fails by default")
+	assert.Empty(d.Id(), "Nothing is saved to the state")
+
+	d, errs = create(map[string]interface{}{"type": "service", "name": "ops", "adopt_existing": true})
+	assert.Empty(errs, "No errors")
+	assert.Equal("k3", d.Id(), "The key of the type with the same name is adopted")
+	assert.Equal("secret-3", d.Get("key"), "The key is read back")
+
+	_, errs = create(map[string]interface{}{"type": "service", "adopt_existing": true})
+	assert.Len(errs, 1, "Several keys of the type cannot be told apart")
+	assert.Contains(errs[0], "status 409 NOT OK!", "The conflict is reported")
+	assert.Contains(errs[0], "and it cannot be adopted: 2 service keys exist, expected exactly one; set the name of the key to pick one")
+
+	_, errs = create(map[string]interface{}{"type": "service", "name": "missing", "adopt_existing": true})
+	assert.Len(errs, 1, "A key without match cannot be adopted")
+	assert.Contains(errs[0], `and it cannot be adopted: 0 service keys are named "missing", expected exactly one`)
+}
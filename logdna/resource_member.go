@@ -0,0 +1,222 @@
+package logdna
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// memberRoles are the roles a team member can be given
+var memberRoles = []string{"owner", "admin", "member", "readonly"}
+
+func resourceMemberCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	pc := m.(*providerConfig)
+
+	member := memberRequest{}
+	if diags = member.CreateRequestBody(d); diags.HasError() {
+		return diags
+	}
+
+	req := newRequestConfig(
+		pc,
+		"POST",
+		pc.endpoint("member.create"),
+		member,
+	)
+
+	body, err := req.MakeRequestWithContext(ctx)
+	log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
+
+	if isConflictErr(err) && d.Get("adopt_existing").(bool) {
+		// Already in the team, e.g. invited by a previous run which failed
+		// before saving its state; the role of the config is applied to it
+		log.Printf("[WARN] Member %s already exists, adopting it", member.Email)
+		d.SetId(member.Email)
+		return resourceMemberUpdate(ctx, d, m)
+	}
+	if err != nil {
+		return diag.FromErr(operationError("creating", "logdna_member", d, err))
+	}
+
+	createdMember := memberResponse{}
+	err = json.Unmarshal(body, &createdMember)
+	if err != nil {
+		return diag.FromErr(operationError("creating", "logdna_member", d, err))
+	}
+	log.Printf("[DEBUG] After %s member, the created member is %+v", req.method, createdMember)
+
+	// Members are addressed by their email, there is no other identifier
+	d.SetId(createdMember.Email)
+
+	return resourceMemberRead(ctx, d, m)
+}
+
+func resourceMemberUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	pc := m.(*providerConfig)
+	email := d.Id()
+
+	member := memberRequest{}
+	if diags = member.CreateRequestBody(d); diags.HasError() {
+		return diags
+	}
+	// The email is the identifier of the member, only the role is updated
+	member.Email = ""
+
+	req := newRequestConfig(
+		pc,
+		"PUT",
+		pc.endpoint("member.update", url.PathEscape(email)),
+		member,
+	)
+
+	body, err := req.MakeRequestWithContext(ctx)
+	log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
+
+	if err != nil {
+		return diag.FromErr(operationError("updating", "logdna_member", d, err))
+	}
+
+	log.Printf("[DEBUG] %s %s SUCCESS. Remote resource updated.", req.method, req.apiURL)
+
+	return resourceMemberRead(ctx, d, m)
+}
+
+func resourceMemberRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	pc := m.(*providerConfig)
+	email := d.Id()
+
+	req := newRequestConfig(
+		pc,
+		"GET",
+		pc.endpoint("member.read", url.PathEscape(email)),
+		nil,
+	)
+
+	body, err := req.MakeRequestWithContext(ctx)
+
+	log.Printf("[DEBUG] GET member raw response body %s\n", body)
+	if isNotFoundErr(err) {
+		// Removed from the team outside of Terraform, to be invited again by the plan
+		log.Printf("[WARN] Member %s was not found, removing it from the state", email)
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Cannot read the remote member resource",
+			Detail:   operationError("reading", "logdna_member", d, err).Error(),
+		})
+		return diags
+	}
+
+	member := memberResponse{}
+	err = json.Unmarshal(body, &member)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Cannot unmarshal response from the remote member resource",
+			Detail:   operationError("reading", "logdna_member", d, err).Error(),
+		})
+		return diags
+	}
+	log.Printf("[DEBUG] The GET member structure is as follows: %+v\n", member)
+
+	// Top level keys can be set directly
+	appendError(d.Set("email", member.Email), &diags)
+	appendError(d.Set("role", member.Role), &diags)
+	appendError(d.Set("groups", member.Groups), &diags)
+
+	return diags
+}
+
+func resourceMemberDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pc := m.(*providerConfig)
+	email := d.Id()
+
+	req := newRequestConfig(
+		pc,
+		"DELETE",
+		pc.endpoint("member.delete", url.PathEscape(email)),
+		nil,
+	)
+
+	body, err := req.MakeRequestWithContext(ctx)
+	log.Printf("[DEBUG] %s %s member %s", req.method, req.apiURL, body)
+
+	if err != nil {
+		return pc.deleteError(d, "logdna_member", err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceMemberCustomizeDiff validates the groups against the account when
+// they change, so that a misspelled group fails the plan
+func resourceMemberCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	pc, ok := m.(*providerConfig)
+	if !ok || pc == nil || !d.HasChange("groups") || !d.NewValueKnown("groups") {
+		return nil
+	}
+	names := listToStrings(d.Get("groups").([]interface{}))
+	groups, err := normalizeMemberGroups(ctx, pc, names)
+	if err != nil {
+		return err
+	}
+	for i := range groups {
+		if groups[i] != names[i] {
+			return d.SetNew("groups", groups)
+		}
+	}
+	return nil
+}
+
+func resourceMember() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceMemberCreate,
+		UpdateContext: resourceMemberUpdate,
+		ReadContext:   resourceMemberRead,
+		DeleteContext: resourceMemberDelete,
+		CustomizeDiff: resourceMemberCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"email": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Required: true,
+			},
+			"role": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(memberRoles, false),
+			},
+			"groups": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
+			"adopt_existing": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
@@ -0,0 +1,189 @@
+package logdna
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMember_Lifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	var methods []string
+	members := map[string]memberResponse{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		email := strings.TrimPrefix(r.URL.Path, "/v1/config/member/")
+
+		switch r.Method {
+		case "POST":
+			assert.Equal("/v1/config/member", r.URL.Path, "Members are created on the collection")
+			payload := memberRequest{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&payload), "No errors")
+			members[payload.Email] = memberResponse{Email: payload.Email, Role: payload.Role, Groups: []string{"everyone"}}
+			assert.Nil(json.NewEncoder(w).Encode(members[payload.Email]), "No errors")
+		case "PUT":
+			payload := memberRequest{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&payload), "No errors")
+			assert.Empty(payload.Email, "Only the role is updated")
+			member := members[email]
+			member.Role = payload.Role
+			members[email] = member
+			assert.Nil(json.NewEncoder(w).Encode(member), "No errors")
+		case "GET":
+			member, ok := members[email]
+			if !ok {
+				w.WriteHeader(404)
+				return
+			}
+			assert.Nil(json.NewEncoder(w).Encode(member), "No errors")
+		case "DELETE":
+			delete(members, email)
+		}
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	d := schema.TestResourceDataRaw(t, resourceMember().Schema, map[string]interface{}{
+		"email": "jane@example.com",
+		"role":  "member",
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		diags := resourceMemberCreate(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal("jane@example.com", d.Id(), "The email identifies the member")
+		assert.Equal([]interface{}{"everyone"}, d.Get("groups"), "Groups are read back")
+	})
+
+	t.Run("Role changes are updated in place", func(t *testing.T) {
+		assert.False(resourceMember().Schema["role"].ForceNew, "The member is not replaced")
+		assert.Nil(d.Set("role", "admin"), "No errors")
+
+		methods = nil
+		diags := resourceMemberUpdate(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal([]string{"PUT", "GET"}, methods, "The role is updated with a PUT")
+		assert.Equal("admin", members["jane@example.com"].Role, "The remote role is updated")
+		assert.Equal("admin", d.Get("role"), "The role is read back")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		diags := resourceMemberDelete(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Empty(d.Id(), "The member is removed from the state")
+		assert.Empty(members, "The remote member is removed")
+	})
+
+	t.Run("Read tolerates members removed outside of Terraform", func(t *testing.T) {
+		d.SetId("gone@example.com")
+		diags := resourceMemberRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Empty(d.Id(), "The member is removed from the state")
+	})
+}
+
+func TestMember_Validation(t *testing.T) {
+	assert := assert.New(t)
+	role := resourceMember().Schema["role"]
+
+	for _, r := range memberRoles {
+		_, errs := role.ValidateFunc(r, "role")
+		assert.Empty(errs, "%s is a valid role", r)
+	}
+	_, errs := role.ValidateFunc("superuser", "role")
+	assert.NotEmpty(errs, "Unknown roles are rejected")
+}
+
+func TestMember_AdoptExisting(t *testing.T) {
+	assert := assert.New(t)
+
+	var methods []string
+	role := "member"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		switch r.Method {
+		case "POST":
+			w.WriteHeader(409)
+			_, err := w.Write([]byte(`{"error": "Member already exists"}`))
+			assert.Nil(err, "No errors")
+		case "PUT":
+			assert.Equal("/v1/config/member/jane@example.com", r.URL.Path, "The existing member is updated")
+			payload := memberRequest{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&payload), "No errors")
+			role = payload.Role
+			assert.Nil(json.NewEncoder(w).Encode(memberResponse{Email: "jane@example.com", Role: role}), "No errors")
+		case "GET":
+			assert.Nil(json.NewEncoder(w).Encode(memberResponse{Email: "jane@example.com", Role: role}), "No errors")
+		}
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	t.Run("Fails by default", func(t *testing.T) {
+		d := schema.TestResourceDataRaw(t, resourceMember().Schema, map[string]interface{}{
+			"email": "jane@example.com",
+			"role":  "admin",
+		})
+		diags := resourceMemberCreate(context.Background(), d, &pc)
+		assert.True(diags.HasError(), "The conflict is reported")
+		assert.Contains(diags[0].Summary, "status 409 NOT OK!", "The conflict is reported")
+		assert.Empty(d.Id(), "Nothing is saved to the state")
+	})
+
+	t.Run("Adopts the existing member", func(t *testing.T) {
+		d := schema.TestResourceDataRaw(t, resourceMember().Schema, map[string]interface{}{
+			"email":          "jane@example.com",
+			"role":           "admin",
+			"adopt_existing": true,
+		})
+		methods = nil
+		diags := resourceMemberCreate(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal([]string{"POST", "PUT", "GET"}, methods, "The role of the config is applied")
+		assert.Equal("jane@example.com", d.Id(), "The member is adopted")
+		assert.Equal("admin", d.Get("role"), "The role is read back")
+	})
+}
+
+func TestMember_Groups(t *testing.T) {
+	assert := assert.New(t)
+
+	listStatus := 200
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/v1/config/groups", r.URL.Path, "The groups of the account are listed")
+		w.WriteHeader(listStatus)
+		_, err := w.Write([]byte(`[{"id": "g1", "name": "SRE"}, {"id": "g2", "name": "Developers"}]`))
+		assert.Nil(err, "No errors")
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	diff := func(groups ...interface{}) (*terraform.InstanceDiff, error) {
+		return resourceMember().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+			"email":  "jane@example.com",
+			"role":   "member",
+			"groups": groups,
+		}), &pc)
+	}
+
+	_, err := diff("SRE", "Developpers")
+	assert.EqualError(err, `group "Developpers" does not exist in the account, expected one of: Developers, SRE`, "Unknown groups fail the plan")
+
+	d, err := diff("SRE", " developers")
+	assert.Nil(err, "No errors")
+	assert.Equal("SRE", d.Attributes["groups.0"].New, "Exact names are kept")
+	assert.Equal("Developers", d.Attributes["groups.1"].New, "The name of the group is used")
+
+	listStatus = 403
+	_, err = diff("Developpers")
+	assert.Nil(err, "Groups which cannot be listed are left to the API")
+}
@@ -0,0 +1,102 @@
+package logdna
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// NOTE logdna_raw is an unsupported escape hatch for API features the provider
+//      does not model yet. The request is sent once on create and the response
+//      is stored as-is; nothing is refreshed, reconciled or cleaned up remotely.
+
+var rawMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+func resourceRawCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	diags := diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "logdna_raw is experimental and unsupported",
+		Detail:   "The request is sent verbatim and its result is not managed by the provider. Prefer a dedicated resource when one exists.",
+	}}
+	pc := m.(*providerConfig)
+
+	var body interface{}
+	if rawBody := d.Get("body").(string); rawBody != "" {
+		body = json.RawMessage(rawBody)
+	}
+
+	path := d.Get("path").(string)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	req := newRequestConfig(
+		pc,
+		d.Get("method").(string),
+		path,
+		body,
+	)
+
+	res, err := req.MakeRequestWithContext(ctx)
+	log.Printf("[DEBUG] %s %s, raw response is: %s", req.method, req.apiURL, res)
+
+	if err != nil {
+		return append(diags, diag.FromErr(operationError("creating", "logdna_raw", d, err))...)
+	}
+
+	d.SetId(resource.UniqueId())
+	appendError(d.Set("response", string(res)), &diags)
+
+	return diags
+}
+
+func resourceRawRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// The response recorded on create is kept; the endpoint is never re-requested
+	return nil
+}
+
+func resourceRawDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	log.Printf("[WARN] Removing logdna_raw %s from state; nothing is deleted remotely", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func resourceRaw() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceRawCreate,
+		ReadContext:   resourceRawRead,
+		DeleteContext: resourceRawDelete,
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"method": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "POST",
+				ValidateFunc: validation.StringInSlice(rawMethods, false),
+			},
+			"body": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"response": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
@@ -0,0 +1,91 @@
+package logdna
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaw_Create(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("Sends the method, path and body verbatim and stores the response", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal("PUT", r.Method, "method is correct")
+			assert.Equal("/v1/config/beta/feature", r.URL.Path, "path is correct")
+			postedBody, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(`{"enabled":true,"level":3}`, string(postedBody), "body is correct")
+			_, err := w.Write([]byte(`{"ok":true}`))
+			assert.Nil(err, "No errors")
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := schema.TestResourceDataRaw(t, resourceRaw().Schema, map[string]interface{}{
+			"path":   "v1/config/beta/feature",
+			"method": "PUT",
+			"body":   `{"enabled": true, "level": 3}`,
+		})
+
+		diags := resourceRawCreate(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal(diag.Warning, diags[0].Severity, "Experimental warning is surfaced")
+		assert.NotEmpty(d.Id(), "ID is set")
+		assert.Equal(`{"ok":true}`, d.Get("response"), "Response is stored")
+	})
+
+	t.Run("Defaults to POST without a body", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal("POST", r.Method, "method is correct")
+			postedBody, _ := ioutil.ReadAll(r.Body)
+			assert.Empty(postedBody, "No body is sent")
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := schema.TestResourceDataRaw(t, resourceRaw().Schema, map[string]interface{}{
+			"path": "/v1/config/beta/trigger",
+		})
+
+		diags := resourceRawCreate(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal("", d.Get("response"), "Empty response is stored")
+	})
+
+	t.Run("Does not set an ID when the request fails", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(400)
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		d := schema.TestResourceDataRaw(t, resourceRaw().Schema, map[string]interface{}{
+			"path": "/v1/config/beta/trigger",
+		})
+
+		diags := resourceRawCreate(context.Background(), d, &pc)
+		assert.True(diags.HasError(), "Expected error")
+		assert.Empty(d.Id(), "No ID is set")
+	})
+}
+
+func TestRaw_Validation(t *testing.T) {
+	assert := assert.New(t)
+	s := resourceRaw().Schema
+
+	_, errs := s["method"].ValidateFunc("TRACE", "method")
+	assert.Len(errs, 1, "Unsupported methods are rejected")
+
+	_, errs = s["body"].ValidateFunc(`{"nope"`, "body")
+	assert.Len(errs, 1, "Invalid JSON bodies are rejected")
+
+	_, errs = s["body"].ValidateFunc(`{"yes":1}`, "body")
+	assert.Empty(errs, "Valid JSON bodies are accepted")
+}
@@ -32,19 +32,13 @@ func resourceStreamConfigCreate(ctx context.Context, d *schema.ResourceData, m i
 	req := newRequestConfig(
 		pc,
 		"POST",
-		"/v1/config/stream",
+		pc.endpoint("stream_config.create"),
 		c,
 	)
 
-	body, err := req.MakeRequest()
+	cn, err := Do[streamConfig](ctx, req)
 	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	cn := streamConfig{}
-	err = json.Unmarshal(body, &cn)
-	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_stream_config", d, err))
 	}
 
 	d.SetId(streamConfigID)
@@ -63,17 +57,17 @@ func resourceStreamConfigRead(ctx context.Context, d *schema.ResourceData, m int
 	req := newRequestConfig(
 		pc,
 		"GET",
-		"/v1/config/stream",
+		pc.endpoint("stream_config.read"),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot read the remote stream config resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_stream_config", d, err).Error(),
 		})
 		return diags
 	}
@@ -84,7 +78,7 @@ func resourceStreamConfigRead(ctx context.Context, d *schema.ResourceData, m int
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot unmarshal response from the remote stream config resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_stream_config", d, err).Error(),
 		})
 		return diags
 	}
@@ -109,13 +103,13 @@ func resourceStreamConfigUpdate(ctx context.Context, d *schema.ResourceData, m i
 	req := newRequestConfig(
 		pc,
 		"PUT",
-		"/v1/config/stream",
+		pc.endpoint("stream_config.update"),
 		c,
 	)
 
-	_, err := req.MakeRequest()
+	_, err := req.MakeRequestWithContext(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("updating", "logdna_stream_config", d, err))
 	}
 
 	return resourceStreamConfigRead(ctx, d, m)
@@ -126,13 +120,13 @@ func resourceStreamConfigDelete(ctx context.Context, d *schema.ResourceData, m i
 	req := newRequestConfig(
 		pc,
 		"DELETE",
-		"/v1/config/stream",
+		pc.endpoint("stream_config.delete"),
 		nil,
 	)
 
-	_, err := req.MakeRequest()
+	_, err := req.MakeRequestWithContext(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return pc.deleteError(d, "logdna_stream_config", err)
 	}
 
 	d.SetId("")
@@ -23,7 +23,7 @@ func TestStreamConfig_expectInvalidURLError(t *testing.T) {
 					user = "test-user"
 					password = "test-password"
 				`, "http://api.logdna.co"),
-				ExpectError: regexp.MustCompile("Error: error during HTTP request: Post \"http://api.logdna.co/v1/config/stream\": dial tcp: lookup api.logdna.co"),
+				ExpectError: regexp.MustCompile("Error: creating logdna_stream_config.*: error during HTTP request: Post \"http://api.logdna.co/v1/config/stream\": dial tcp: lookup api.logdna.co"),
 			},
 		},
 	})
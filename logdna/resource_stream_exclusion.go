@@ -3,7 +3,6 @@ package logdna
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -15,7 +14,7 @@ func resourceStreamExclusionCreate(ctx context.Context, d *schema.ResourceData,
 	pc := m.(*providerConfig)
 	ex := exclusionRule{
 		Title:  d.Get("title").(string),
-		Active: d.Get("active").(bool),
+		Active: flexBool(d.Get("active").(bool)),
 		Apps:   listToStrings(d.Get("apps").([]interface{})),
 		Hosts:  listToStrings(d.Get("hosts").([]interface{})),
 		Query:  d.Get("query").(string),
@@ -24,24 +23,18 @@ func resourceStreamExclusionCreate(ctx context.Context, d *schema.ResourceData,
 	req := newRequestConfig(
 		pc,
 		"POST",
-		"/v1/config/stream/exclusions",
+		pc.endpoint("stream_exclusion.create"),
 		ex,
 	)
 
-	body, err := req.MakeRequest()
+	exn, err := Do[exclusionRule](ctx, req)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_stream_exclusion", d, err))
 	}
 
-	exn := exclusionRule{}
-	err = json.Unmarshal(body, &exn)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
-	d.SetId(exn.ID)
+	d.SetId(string(exn.ID))
 	appendError(d.Set("title", exn.Title), &diags)
-	appendError(d.Set("active", exn.Active), &diags)
+	appendError(d.Set("active", bool(exn.Active)), &diags)
 	appendError(d.Set("apps", exn.Apps), &diags)
 	appendError(d.Set("hosts", exn.Hosts), &diags)
 	appendError(d.Set("query", exn.Query), &diags)
@@ -56,16 +49,16 @@ func resourceStreamExclusionRead(ctx context.Context, d *schema.ResourceData, m
 	req := newRequestConfig(
 		pc,
 		"GET",
-		fmt.Sprintf("/v1/config/stream/exclusions/%s", d.Id()),
+		pc.endpoint("stream_exclusion.read", d.Id()),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot read the remote stream exclusion resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_stream_exclusion", d, err).Error(),
 		})
 		return diags
 	}
@@ -76,13 +69,13 @@ func resourceStreamExclusionRead(ctx context.Context, d *schema.ResourceData, m
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot unmarshal response from the remote stream exclusion resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_stream_exclusion", d, err).Error(),
 		})
 		return diags
 	}
 
 	appendError(d.Set("title", ex.Title), &diags)
-	appendError(d.Set("active", ex.Active), &diags)
+	appendError(d.Set("active", bool(ex.Active)), &diags)
 	appendError(d.Set("apps", ex.Apps), &diags)
 	appendError(d.Set("hosts", ex.Hosts), &diags)
 	appendError(d.Set("query", ex.Query), &diags)
@@ -94,7 +87,7 @@ func resourceStreamExclusionUpdate(ctx context.Context, d *schema.ResourceData,
 	pc := m.(*providerConfig)
 	ex := exclusionRule{
 		Title:  d.Get("title").(string),
-		Active: d.Get("active").(bool),
+		Active: flexBool(d.Get("active").(bool)),
 		Apps:   listToStrings(d.Get("apps").([]interface{})),
 		Hosts:  listToStrings(d.Get("hosts").([]interface{})),
 		Query:  d.Get("query").(string),
@@ -103,13 +96,13 @@ func resourceStreamExclusionUpdate(ctx context.Context, d *schema.ResourceData,
 	req := newRequestConfig(
 		pc,
 		"PATCH",
-		fmt.Sprintf("/v1/config/stream/exclusions/%s", d.Id()),
+		pc.endpoint("stream_exclusion.update", d.Id()),
 		ex,
 	)
 
-	_, err := req.MakeRequest()
+	_, err := req.MakeRequestWithContext(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("updating", "logdna_stream_exclusion", d, err))
 	}
 
 	return resourceStreamExclusionRead(ctx, d, m)
@@ -120,13 +113,13 @@ func resourceStreamExclusionDelete(ctx context.Context, d *schema.ResourceData,
 	req := newRequestConfig(
 		pc,
 		"DELETE",
-		fmt.Sprintf("/v1/config/stream/exclusions/%s", d.Id()),
+		pc.endpoint("stream_exclusion.delete", d.Id()),
 		nil,
 	)
 
-	_, err := req.MakeRequest()
+	_, err := req.MakeRequestWithContext(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return pc.deleteError(d, "logdna_stream_exclusion", err)
 	}
 
 	d.SetId("")
@@ -17,7 +17,7 @@ func TestStreamExclusion_expectInvalidURLError(t *testing.T) {
 					title = "test-title"
 					query = "query-foo AND query-bar"			
 				`, "http://api.logdna.co"),
-				ExpectError: regexp.MustCompile("Error: error during HTTP request: Post \"http://api.logdna.co/v1/config/stream/exclusions\": dial tcp: lookup api.logdna.co"),
+				ExpectError: regexp.MustCompile("Error: creating logdna_stream_exclusion.*: error during HTTP request: Post \"http://api.logdna.co/v1/config/stream/exclusions\": dial tcp: lookup api.logdna.co"),
 			},
 		},
 	})
@@ -10,6 +10,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // Constants for identifying channel names easily
@@ -20,6 +21,10 @@ const (
 	WEBHOOK   = "webhook"
 )
 
+// viewMatches combine the apps, hosts, levels, tags and query of a view with
+// AND (all) or OR (any)
+var viewMatches = []string{"all", "any"}
+
 func resourceViewCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 	pc := m.(*providerConfig)
@@ -33,25 +38,25 @@ func resourceViewCreate(ctx context.Context, d *schema.ResourceData, m interface
 	req := newRequestConfig(
 		pc,
 		"POST",
-		"/v1/config/view",
+		pc.endpoint("view.create"),
 		view,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_view", d, err))
 	}
 
 	createdView := viewResponse{}
 	err = json.Unmarshal(body, &createdView)
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("creating", "logdna_view", d, err))
 	}
 	log.Printf("[DEBUG] After %s view, the created view is %+v", req.method, createdView)
 
-	d.SetId(createdView.ViewID)
+	d.SetId(string(createdView.ViewID))
 
 	return resourceViewRead(ctx, d, m)
 }
@@ -62,52 +67,73 @@ func resourceViewRead(ctx context.Context, d *schema.ResourceData, m interface{}
 	pc := m.(*providerConfig)
 	viewID := d.Id()
 
-	req := newRequestConfig(
-		pc,
-		"GET",
-		fmt.Sprintf("/v1/config/view/%s", viewID),
-		nil,
-	)
-
-	body, err := req.MakeRequest()
-
-	log.Printf("[DEBUG] GET view raw response body %s\n", body)
-	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Cannot read the remote view resource",
-			Detail:   err.Error(),
-		})
-		return diags
+	body, cached := pc.viewCache.take(ctx, pc, viewID)
+	if !cached {
+		req := newRequestConfig(
+			pc,
+			"GET",
+			pc.endpoint("view.read", viewID),
+			nil,
+		)
+
+		var err error
+		body, err = req.MakeRequestWithContext(ctx)
+
+		log.Printf("[DEBUG] GET view raw response body %s\n", body)
+		if isNotFoundErr(err) {
+			// Deleted outside of Terraform, to be recreated by the plan
+			log.Printf("[WARN] View %s was not found, removing it from the state", viewID)
+			d.SetId("")
+			return diags
+		}
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Cannot read the remote view resource",
+				Detail:   operationError("reading", "logdna_view", d, err).Error(),
+			})
+			return diags
+		}
 	}
 
 	view := viewResponse{}
-	err = json.Unmarshal(body, &view)
+	err := json.Unmarshal(body, &view)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Cannot unmarshal response from the remote view resource",
-			Detail:   err.Error(),
+			Detail:   operationError("reading", "logdna_view", d, err).Error(),
 		})
 		return diags
 	}
 	log.Printf("[DEBUG] The GET view structure is as follows: %+v\n", view)
+	ignored := ignoredFields(d)
+
+	// The negated apps and hosts are read back from the end of the query
+	hosts, apps := []string(view.Hosts), []string(view.Apps)
+	query, negated := withoutExclusions(view.Query, exclusionTerms(d))
+	if negated {
+		hosts = restoreNegations(listToStrings(d.Get("hosts").([]interface{})), hosts)
+		apps = restoreNegations(listToStrings(d.Get("apps").([]interface{})), apps)
+	}
 
 	// Top level keys can be set directly
 	appendError(d.Set("name", view.Name), &diags)
-	appendError(d.Set("query", view.Query), &diags)
+	appendError(d.Set("query", query), &diags)
+	appendError(d.Set("match", view.Match), &diags)
 	appendError(d.Set("categories", view.Category), &diags)
-	appendError(d.Set("hosts", view.Hosts), &diags)
+	appendError(d.Set("hosts", hosts), &diags)
 	appendError(d.Set("tags", view.Tags), &diags)
-	appendError(d.Set("apps", view.Apps), &diags)
+	appendError(d.Set("apps", apps), &diags)
 	appendError(d.Set("levels", view.Levels), &diags)
 	// NOTE There is always one element in the PresetIds slice
-	appendError(d.Set("presetid", strings.Join(view.PresetIds, "")), &diags)
+	appendError(d.Set("presetid", strings.Join(view.presetIDs(), "")), &diags)
 
 	// NOTE API does DB denormalization and extend a view record in DB
 	//      with a alert channels which break a schema validation here.
 	//      We don't need the channels field in case when a presetid exists 
 	if len(d.Get("presetid").(string)) > 0 {
+		restoreIgnoredFields(d, ignored, &diags)
 		return diags
 	}
 
@@ -119,8 +145,14 @@ func resourceViewRead(ctx context.Context, d *schema.ResourceData, m interface{}
 	// integrations since we have done a PUT operation. Thus, remove non-existing things.
 	for name, value := range integrations {
 		schemaKey := fmt.Sprintf("%s_channel", name)
+		prior := d.Get(schemaKey).([]interface{})
+		value = orderChannelsByID(prior, value)
+		if name == WEBHOOK {
+			value = keepWebhookSecrets(prior, value)
+		}
 		appendError(d.Set(schemaKey, value), &diags)
 	}
+	restoreIgnoredFields(d, ignored, &diags)
 
 	return diags
 }
@@ -130,23 +162,25 @@ func resourceViewUpdate(ctx context.Context, d *schema.ResourceData, m interface
 	pc := m.(*providerConfig)
 	viewID := d.Id()
 	view := viewRequest{}
+	pc.viewCache.invalidate(viewID)
 
 	if diags = view.CreateRequestBody(d); diags.HasError() {
 		return diags
 	}
+	dropShiftedChannelIDs(d, view.Channels)
 
 	req := newRequestConfig(
 		pc,
 		"PUT",
-		fmt.Sprintf("/v1/config/view/%s", viewID),
+		pc.endpoint("view.update", viewID),
 		view,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return diag.FromErr(operationError("updating", "logdna_view", d, err))
 	}
 
 	log.Printf("[DEBUG] %s %s SUCCESS. Remote resource updated.", req.method, req.apiURL)
@@ -158,29 +192,60 @@ func resourceViewDelete(ctx context.Context, d *schema.ResourceData, m interface
 	pc := m.(*providerConfig)
 	viewID := d.Id()
 
+	pc.viewCache.invalidate(viewID)
+
 	req := newRequestConfig(
 		pc,
 		"DELETE",
-		fmt.Sprintf("/v1/config/view/%s", viewID),
+		pc.endpoint("view.delete", viewID),
 		nil,
 	)
 
-	body, err := req.MakeRequest()
+	body, err := req.MakeRequestWithContext(ctx)
 	log.Printf("[DEBUG] %s %s view %s", req.method, req.apiURL, body)
 
 	if err != nil {
-		return diag.FromErr(err)
+		return pc.deleteError(d, "logdna_view", err)
 	}
 	d.SetId("")
 	return nil
 }
 
+func resourceViewCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := validateChannelCount(d); err != nil {
+		return err
+	}
+	if err := validateWebhookBodyTemplates(d); err != nil {
+		return err
+	}
+	if err := validateTriggerIntervals(d); err != nil {
+		return err
+	}
+	if err := validateNegations(d); err != nil {
+		return err
+	}
+	// Checked against the API only when the categories change, to keep the
+	// plans of unchanged views offline
+	if pc, ok := m.(*providerConfig); ok && pc != nil && d.HasChange("categories") && d.NewValueKnown("categories") {
+		categories := listToStrings(d.Get("categories").([]interface{}))
+		if err := validateCategoryType(ctx, pc, "logdna_view", "views", categories); err != nil {
+			return err
+		}
+	}
+	view := viewRequest{}
+	if diags := view.CreateRequestBody(d); !diags.HasError() {
+		previewRequestBody("logdna_view", view.Name, view)
+	}
+	return nil
+}
+
 func resourceView() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceViewCreate,
 		ReadContext:   resourceViewRead,
 		UpdateContext: resourceViewUpdate,
 		DeleteContext: resourceViewDelete,
+		CustomizeDiff: resourceViewCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -189,7 +254,10 @@ func resourceView() *schema.Resource {
 			"apps": {
 				Type:     schema.TypeList,
 				Optional: true,
-				Elem:     &schema.Schema{Type: schema.TypeString},
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateViewEntry,
+				},
 			},
 			"categories": {
 				Type:     schema.TypeList,
@@ -209,8 +277,21 @@ func resourceView() *schema.Resource {
 			"hosts": {
 				Type:     schema.TypeList,
 				Optional: true,
-				Elem:     &schema.Schema{Type: schema.TypeString},
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateViewEntry,
+				},
 			},
+			"ignore_fields": ignoreFieldsSchema(append([]string{
+				"apps",
+				"categories",
+				"hosts",
+				"levels",
+				"name",
+				"query",
+				"match",
+				"presetid",
+			}, channelFields...)),
 			"levels": {
 				Type:     schema.TypeList,
 				Optional: true,
@@ -224,6 +305,12 @@ func resourceView() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"match": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice(viewMatches, false),
+			},
 			"presetid": {
 				Type:          schema.TypeString,
 				Optional:      true,
@@ -244,13 +331,24 @@ func resourceView() *schema.Resource {
 				Optional: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"channelid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
 						"emails": {
 							Type:     schema.TypeList,
 							Required: true,
 							Elem: &schema.Schema{
-								Type: schema.TypeString,
+								Type:         schema.TypeString,
+								ValidateFunc: validateEmailAddress,
 							},
 						},
+						"enabled": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "true",
+							ValidateFunc: validation.StringInSlice([]string{"true", "false"}, false),
+						},
 						"immediate": {
 							Type:     schema.TypeString,
 							Optional: true,
@@ -261,6 +359,16 @@ func resourceView() *schema.Resource {
 							Optional: true,
 							Default:  "presence",
 						},
+						"sensitivity": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice(anomalySensitivities, false),
+						},
+						"zscore": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							ValidateFunc: validation.FloatBetween(minZScore, maxZScore),
+						},
 						"terminal": {
 							Type:     schema.TypeString,
 							Optional: true,
@@ -272,7 +380,8 @@ func resourceView() *schema.Resource {
 						},
 						"triggerlimit": {
 							Type:     schema.TypeInt,
-							Required: true,
+							Optional: true,
+							Computed: true,
 							ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
 								v := val.(int)
 								if v < 1 || v > 100000 {
@@ -282,8 +391,10 @@ func resourceView() *schema.Resource {
 							},
 						},
 						"triggerinterval": {
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateTriggerInterval,
+							StateFunc:    triggerIntervalState,
 						},
 					},
 				},
@@ -293,6 +404,16 @@ func resourceView() *schema.Resource {
 				Optional: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"channelid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"enabled": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "true",
+							ValidateFunc: validation.StringInSlice([]string{"true", "false"}, false),
+						},
 						"immediate": {
 							Type:     schema.TypeString,
 							Optional: true,
@@ -307,18 +428,31 @@ func resourceView() *schema.Resource {
 							Optional: true,
 							Default:  "presence",
 						},
+						"sensitivity": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice(anomalySensitivities, false),
+						},
+						"zscore": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							ValidateFunc: validation.FloatBetween(minZScore, maxZScore),
+						},
 						"terminal": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "false",
 						},
 						"triggerinterval": {
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateTriggerInterval,
+							StateFunc:    triggerIntervalState,
 						},
 						"triggerlimit": {
 							Type:     schema.TypeInt,
-							Required: true,
+							Optional: true,
+							Computed: true,
 							ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
 								v := val.(int)
 								if v < 1 || v > 100000 {
@@ -335,6 +469,16 @@ func resourceView() *schema.Resource {
 				Optional: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"channelid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"enabled": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "true",
+							ValidateFunc: validation.StringInSlice([]string{"true", "false"}, false),
+						},
 						"immediate": {
 							Type:     schema.TypeString,
 							Optional: true,
@@ -345,18 +489,31 @@ func resourceView() *schema.Resource {
 							Optional: true,
 							Default:  "presence",
 						},
+						"sensitivity": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice(anomalySensitivities, false),
+						},
+						"zscore": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							ValidateFunc: validation.FloatBetween(minZScore, maxZScore),
+						},
 						"terminal": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "false",
 						},
 						"triggerinterval": {
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateTriggerInterval,
+							StateFunc:    triggerIntervalState,
 						},
 						"triggerlimit": {
 							Type:     schema.TypeInt,
-							Required: true,
+							Optional: true,
+							Computed: true,
 							ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
 								v := val.(int)
 								if v < 1 || v > 100000 {
@@ -377,6 +534,10 @@ func resourceView() *schema.Resource {
 				Optional: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"channelid": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
 						"bodytemplate": {
 							Type:     schema.TypeString,
 							Optional: true,
@@ -405,32 +566,63 @@ func resourceView() *schema.Resource {
 							},
 							Optional: true,
 						},
+						"secret": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"secretheader": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      defaultWebhookSecretHeader,
+							ValidateFunc: validation.StringIsNotWhiteSpace,
+						},
+						"enabled": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "true",
+							ValidateFunc: validation.StringInSlice([]string{"true", "false"}, false),
+						},
 						"immediate": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "false",
 						},
 						"method": {
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:             schema.TypeString,
+							Optional:         true,
+							DiffSuppressFunc: suppressDefaultWebhookMethod,
 						},
 						"operator": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "presence",
 						},
+						"sensitivity": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice(anomalySensitivities, false),
+						},
+						"zscore": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							ValidateFunc: validation.FloatBetween(minZScore, maxZScore),
+						},
 						"terminal": {
 							Type:     schema.TypeString,
 							Optional: true,
 							Default:  "false",
 						},
 						"triggerinterval": {
-							Type:     schema.TypeString,
-							Optional: true,
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateTriggerInterval,
+							StateFunc:    triggerIntervalState,
 						},
 						"triggerlimit": {
 							Type:     schema.TypeInt,
-							Required: true,
+							Optional: true,
+							Computed: true,
 							ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
 								v := val.(int)
 								if v < 1 || v > 100000 {
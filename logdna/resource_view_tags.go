@@ -0,0 +1,201 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// NOTE logdna_view_tags only owns the tags it applies: the other tags of the
+//      views, and the views themselves, are managed elsewhere and left as-is.
+
+// setViewTags adds and removes tags from a view, keeping its other tags in
+// their order. The view is only patched when its tags change.
+func setViewTags(ctx context.Context, pc *providerConfig, viewID string, add []string, remove []string) error {
+	view, err := Do[viewResponse](ctx, newRequestConfig(pc, "GET", pc.endpoint("view.read", viewID), nil))
+	if err != nil {
+		return err
+	}
+
+	adding := stringSet(add)
+	removing := stringSet(remove)
+	tags := make([]string, 0, len(view.Tags)+len(add))
+	present := map[string]bool{}
+	changed := false
+	for _, tag := range view.Tags {
+		if removing[tag] && !adding[tag] {
+			changed = true
+			continue
+		}
+		tags = append(tags, tag)
+		present[tag] = true
+	}
+	for _, tag := range add {
+		if !present[tag] {
+			tags = append(tags, tag)
+			present[tag] = true
+			changed = true
+		}
+	}
+	if !changed {
+		log.Printf("[DEBUG] The tags of view %s are up to date", viewID)
+		return nil
+	}
+
+	req := newRequestConfig(pc, "PATCH", pc.endpoint("view_tags.update", viewID), viewTagsRequest{Tags: tags})
+	body, err := req.MakeRequestWithContext(ctx)
+	log.Printf("[DEBUG] %s %s, payload is: %s", req.method, req.apiURL, body)
+	pc.viewCache.invalidate(viewID)
+	return err
+}
+
+// stringSet indexes strs for lookups
+func stringSet(strs []string) map[string]bool {
+	set := make(map[string]bool, len(strs))
+	for _, s := range strs {
+		set[s] = true
+	}
+	return set
+}
+
+// difference returns the strings of a which are not in b
+func difference(a []string, b []string) []string {
+	exclude := stringSet(b)
+	diff := []string{}
+	for _, s := range a {
+		if !exclude[s] {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+func resourceViewTagsCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pc := m.(*providerConfig)
+	viewIDs := listToStrings(d.Get("view_ids").(*schema.Set).List())
+	tags := listToStrings(d.Get("tags").(*schema.Set).List())
+
+	for _, viewID := range viewIDs {
+		if err := setViewTags(ctx, pc, viewID, tags, nil); err != nil {
+			return diag.FromErr(operationError("creating", "logdna_view_tags", d, fmt.Errorf("view %s: %w", viewID, err)))
+		}
+	}
+
+	// The tags of several views have no identifier of their own
+	d.SetId(resource.UniqueId())
+
+	return resourceViewTagsRead(ctx, d, m)
+}
+
+func resourceViewTagsUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pc := m.(*providerConfig)
+	oldViews, newViews := d.GetChange("view_ids")
+	oldTags, newTags := d.GetChange("tags")
+	managed := listToStrings(oldTags.(*schema.Set).List())
+	tags := listToStrings(newTags.(*schema.Set).List())
+
+	// Views which are no longer tagged lose every tag the resource applied
+	for _, viewID := range difference(listToStrings(oldViews.(*schema.Set).List()), listToStrings(newViews.(*schema.Set).List())) {
+		if err := setViewTags(ctx, pc, viewID, nil, managed); err != nil && !isNotFoundErr(err) {
+			return diag.FromErr(operationError("updating", "logdna_view_tags", d, fmt.Errorf("view %s: %w", viewID, err)))
+		}
+	}
+	for _, viewID := range listToStrings(newViews.(*schema.Set).List()) {
+		if err := setViewTags(ctx, pc, viewID, tags, difference(managed, tags)); err != nil {
+			return diag.FromErr(operationError("updating", "logdna_view_tags", d, fmt.Errorf("view %s: %w", viewID, err)))
+		}
+	}
+
+	return resourceViewTagsRead(ctx, d, m)
+}
+
+// resourceViewTagsRead keeps the views which still exist, and the tags which
+// are still applied to every one of them, so that a tag removed outside of
+// Terraform is applied again by the next plan
+func resourceViewTagsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	pc := m.(*providerConfig)
+	tags := listToStrings(d.Get("tags").(*schema.Set).List())
+
+	viewIDs := []string{}
+	for _, viewID := range listToStrings(d.Get("view_ids").(*schema.Set).List()) {
+		view, err := Do[viewResponse](ctx, newRequestConfig(pc, "GET", pc.endpoint("view.read", viewID), nil))
+		if isNotFoundErr(err) {
+			log.Printf("[WARN] View %s was not found, removing it from the view_ids of %s", viewID, d.Id())
+			continue
+		}
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Cannot read the remote view of the view tags resource",
+				Detail:   operationError("reading", "logdna_view_tags", d, fmt.Errorf("view %s: %w", viewID, err)).Error(),
+			})
+			return diags
+		}
+		viewIDs = append(viewIDs, viewID)
+		present := stringSet(view.Tags)
+		applied := []string{}
+		for _, tag := range tags {
+			if present[tag] {
+				applied = append(applied, tag)
+			}
+		}
+		tags = applied
+	}
+	log.Printf("[DEBUG] Tags %v are applied to views %v", tags, viewIDs)
+
+	appendError(d.Set("view_ids", viewIDs), &diags)
+	appendError(d.Set("tags", tags), &diags)
+
+	return diags
+}
+
+func resourceViewTagsDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	pc := m.(*providerConfig)
+	tags := listToStrings(d.Get("tags").(*schema.Set).List())
+
+	for _, viewID := range listToStrings(d.Get("view_ids").(*schema.Set).List()) {
+		err := setViewTags(ctx, pc, viewID, nil, tags)
+		if err != nil && !isNotFoundErr(err) {
+			return pc.deleteError(d, "logdna_view_tags", fmt.Errorf("view %s: %w", viewID, err))
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func resourceViewTags() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceViewTagsCreate,
+		UpdateContext: resourceViewTagsUpdate,
+		ReadContext:   resourceViewTagsRead,
+		DeleteContext: resourceViewTagsDelete,
+
+		Schema: map[string]*schema.Schema{
+			"view_ids": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
+			"tags": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package logdna
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewTags_Lifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	views := map[string][]string{
+		"view-1": {"owned-elsewhere"},
+		"view-2": {},
+		"view-3": {"prod"},
+	}
+	var patched []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewID := strings.TrimPrefix(r.URL.Path, "/v1/config/view/")
+		tags, ok := views[viewID]
+		if !ok {
+			w.WriteHeader(404)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			assert.Nil(json.NewEncoder(w).Encode(viewResponse{ViewID: flexID(viewID), Name: viewID, Tags: tags}), "No errors")
+		case "PATCH":
+			patched = append(patched, viewID)
+			payload := map[string]interface{}{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&payload), "No errors")
+			assert.Len(payload, 1, "Only the tags are sent")
+			views[viewID] = listToStrings(payload["tags"].([]interface{}))
+			assert.Nil(json.NewEncoder(w).Encode(viewResponse{ViewID: flexID(viewID), Tags: views[viewID]}), "No errors")
+		default:
+			t.Errorf("Unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceViewTags()
+	ctx := context.Background()
+
+	var state *terraform.InstanceState
+	apply := func(cfg map[string]interface{}) {
+		diff, err := rs.Diff(ctx, state, terraform.NewResourceConfigRaw(cfg), &pc)
+		assert.Nil(err, "No errors")
+		var diags diag.Diagnostics
+		state, diags = rs.Apply(ctx, state, diff, &pc)
+		assert.False(diags.HasError(), "No errors")
+	}
+
+	t.Run("Applies the tags to every view", func(t *testing.T) {
+		apply(map[string]interface{}{
+			"view_ids": []interface{}{"view-1", "view-2"},
+			"tags":     []interface{}{"prod", "team-a"},
+		})
+		assert.NotEmpty(state.ID, "ID is set")
+		assert.ElementsMatch([]string{"view-1", "view-2"}, patched, "Both views are patched")
+		assert.Equal("owned-elsewhere", views["view-1"][0], "Other tags are kept first")
+		assert.ElementsMatch([]string{"owned-elsewhere", "prod", "team-a"}, views["view-1"], "Other tags are kept")
+		assert.ElementsMatch([]string{"prod", "team-a"}, views["view-2"])
+		assert.Equal([]string{"prod"}, views["view-3"], "Other views are untouched")
+	})
+
+	t.Run("Reconciles tags removed outside of Terraform on read", func(t *testing.T) {
+		views["view-2"] = []string{"prod"}
+
+		d := rs.Data(state)
+		diags := resourceViewTagsRead(ctx, d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal([]interface{}{"prod"}, d.Get("tags").(*schema.Set).List(), "Only the tags applied to every view are kept")
+		state = d.State()
+
+		patched = nil
+		apply(map[string]interface{}{
+			"view_ids": []interface{}{"view-1", "view-2"},
+			"tags":     []interface{}{"prod", "team-a"},
+		})
+		assert.Equal([]string{"view-2"}, patched, "Only the drifted view is patched")
+		assert.Equal([]string{"prod", "team-a"}, views["view-2"], "The tag is applied again")
+	})
+
+	t.Run("Untags the views and tags removed from the config", func(t *testing.T) {
+		apply(map[string]interface{}{
+			"view_ids": []interface{}{"view-2", "view-3"},
+			"tags":     []interface{}{"prod"},
+		})
+		assert.Equal([]string{"owned-elsewhere"}, views["view-1"], "The view left is untagged")
+		assert.Equal([]string{"prod"}, views["view-2"], "The tag left is removed")
+		assert.Equal([]string{"prod"}, views["view-3"], "Tags the view already had are not duplicated")
+	})
+
+	t.Run("Drops the views deleted outside of Terraform on read", func(t *testing.T) {
+		delete(views, "view-3")
+
+		d := rs.Data(state)
+		diags := resourceViewTagsRead(ctx, d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal([]interface{}{"view-2"}, d.Get("view_ids").(*schema.Set).List(), "The missing view is dropped")
+		state = d.State()
+	})
+
+	t.Run("Delete removes the tags it applied only", func(t *testing.T) {
+		views["view-2"] = []string{"prod", "manual"}
+
+		diags := resourceViewTagsDelete(ctx, rs.Data(state), &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal([]string{"manual"}, views["view-2"], "Other tags are kept")
+	})
+}
@@ -1,11 +1,20 @@
 package logdna
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
 )
 
 const ctgies = `["DEMOCATEGORY1", "DemoCategory2"]`
@@ -20,7 +29,7 @@ func TestView_ErrorProviderUrl(t *testing.T) {
 		Steps: []resource.TestStep{
 			{
 				Config:      fmtTestConfigResource("view", "new", pcArgs, viewDefaults, nilOpt, nilLst),
-				ExpectError: regexp.MustCompile("Error: error during HTTP request: Post \"https://api.logdna.co/v1/config/view\": dial tcp: lookup api.logdna.co"),
+				ExpectError: regexp.MustCompile("Error: creating logdna_view.*: error during HTTP request: Post \"https://api.logdna.co/v1/config/view\": dial tcp: lookup api.logdna.co"),
 			},
 		},
 	})
@@ -312,8 +321,8 @@ func TestView_BulkChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_view.new", "name", "test"),
 					resource.TestCheckResourceAttr("logdna_view.new", "query", "test"),
 					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.#", "2"),
-					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.0.%", "7"),
-					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.1.%", "7"),
+					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.0.%", "11"),
+					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.1.%", "11"),
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.#", "0"),
@@ -326,8 +335,8 @@ func TestView_BulkChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_view.new", "name", "test"),
 					resource.TestCheckResourceAttr("logdna_view.new", "query", "test"),
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.#", "2"),
-					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.0.%", "6"),
-					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.1.%", "6"),
+					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.0.%", "10"),
+					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.1.%", "10"),
 					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.#", "0"),
@@ -340,8 +349,8 @@ func TestView_BulkChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_view.new", "name", "test"),
 					resource.TestCheckResourceAttr("logdna_view.new", "query", "test"),
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.#", "2"),
-					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.0.%", "6"),
-					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.1.%", "6"),
+					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.0.%", "10"),
+					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.1.%", "10"),
 					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.#", "0"),
@@ -354,8 +363,8 @@ func TestView_BulkChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_view.new", "name", "test"),
 					resource.TestCheckResourceAttr("logdna_view.new", "query", "test"),
 					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.#", "2"),
-					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.0.%", "9"),
-					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.1.%", "9"),
+					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.0.%", "15"),
+					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.1.%", "15"),
 					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.#", "0"),
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.#", "0"),
@@ -446,7 +455,7 @@ func TestView_MultipleChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.0.triggerinterval", "15m"),
 					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.0.triggerlimit", "15"),
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.#", "1"),
-					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.0.%", "6"),
+					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.0.%", "10"),
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.0.immediate", "false"),
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.0.key", "Your PagerDuty API key goes here"),
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.0.operator", "presence"),
@@ -454,7 +463,7 @@ func TestView_MultipleChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.0.triggerinterval", "15m"),
 					resource.TestCheckResourceAttr("logdna_view.new", "pagerduty_channel.0.triggerlimit", "15"),
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.#", "1"),
-					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.0.%", "6"),
+					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.0.%", "10"),
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.0.immediate", "false"),
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.0.operator", "absence"),
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.0.terminal", "true"),
@@ -462,7 +471,7 @@ func TestView_MultipleChannels(t *testing.T) {
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.0.triggerlimit", "15"),
 					resource.TestCheckResourceAttr("logdna_view.new", "slack_channel.0.url", "https://hooks.slack.com/services/identifier/secret"),
 					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.#", "1"),
-					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.0.%", "9"),
+					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.0.%", "15"),
 					// The JSON will have newlines per our API which uses JSON.stringify(obj, null, 2) as the value
 					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.0.bodytemplate", "{\n  \"fields\": {\n    \"description\": \"{{ matches }} matches found for {{ name }}\",\n    \"issuetype\": {\n      \"name\": \"Bug\"\n    },\n    \"project\": {\n      \"key\": \"test\"\n    },\n    \"summary\": \"Alert from {{ name }}\"\n  }\n}"),
 					resource.TestCheckResourceAttr("logdna_view.new", "webhook_channel.0.headers.%", "2"),
@@ -625,6 +634,66 @@ func TestView_PresetAlert(t *testing.T) {
 	})
 }
 
+func TestView_DynamicChannels(t *testing.T) {
+	// Channels emitted by a module output are typically expanded with a dynamic
+	// block; each object must map onto the typed channel schema
+	fmtCfg := func(emails ...string) string {
+		quoted := make([]string, 0, len(emails))
+		for _, email := range emails {
+			quoted = append(quoted, fmt.Sprintf(`{ emails = [%q], operator = "presence", triggerlimit = 15 }`, email))
+		}
+		return fmt.Sprintf(`%s
+locals {
+	email_channels = [%s]
+}
+
+resource "logdna_view" "new" {
+	name  = "test"
+	query = "test"
+
+	dynamic "email_channel" {
+		for_each = local.email_channels
+		content {
+			emails          = email_channel.value.emails
+			operator        = email_channel.value.operator
+			triggerlimit    = email_channel.value.triggerlimit
+			triggerinterval = "15m"
+		}
+	}
+}`, fmtProviderBlock(globalPcArgs...), strings.Join(quoted, ", "))
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmtCfg("test@logdna.com", "test2@logdna.com"),
+				Check: resource.ComposeTestCheckFunc(
+					testResourceExists("view", "new"),
+					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.#", "2"),
+					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.0.emails.0", "test@logdna.com"),
+					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.1.emails.0", "test2@logdna.com"),
+					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.1.triggerinterval", "15m"),
+				),
+			},
+			{
+				// Adding an object to the module output adds a channel
+				Config: fmtCfg("test@logdna.com", "test2@logdna.com", "test3@logdna.com"),
+				Check: resource.ComposeTestCheckFunc(
+					testResourceExists("view", "new"),
+					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.#", "3"),
+					resource.TestCheckResourceAttr("logdna_view.new", "email_channel.2.emails.0", "test3@logdna.com"),
+				),
+			},
+			{
+				ResourceName:      "logdna_view.new",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func TestView_ErrorsConflictPresetId(t *testing.T) {
 	chArgs := map[string]map[string]string{
 		"email":     cloneDefaults(chnlDefaults["email"]),
@@ -652,3 +721,690 @@ func TestView_ErrorsConflictPresetId(t *testing.T) {
 		},
 	})
 }
+
+func TestView_TriggerLimitServerDefault(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"viewID": "abc",
+			"name": "test",
+			"channels": [{
+				"integration": "email",
+				"emails": ["test@logdna.com"],
+				"operator": "presence",
+				"triggerlimit": 15
+			}]
+		}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceView()
+	cfg := map[string]interface{}{
+		"name": "test",
+		"email_channel": []interface{}{
+			map[string]interface{}{"emails": []interface{}{"test@logdna.com"}},
+		},
+	}
+	d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+	d.SetId("abc")
+
+	diags := resourceViewRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal(15, d.Get("email_channel.0.triggerlimit"), "Server default is read back")
+
+	diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	assert.True(diff.Empty(), "No diff after refresh: %v", diff)
+}
+
+func TestView_StableChannelOrder(t *testing.T) {
+	assert := assert.New(t)
+	channels := []string{
+		`{"alertid": "a1", "integration": "email", "emails": ["a@logdna.com"], "operator": "presence", "triggerlimit": 15}`,
+		`{"alertid": "b2", "integration": "email", "emails": ["b@logdna.com"], "operator": "presence", "triggerlimit": 15}`,
+	}
+	reversed := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		first, second := channels[0], channels[1]
+		if reversed {
+			first, second = second, first
+		}
+		fmt.Fprintf(w, `{"viewID": "abc", "name": "test", "channels": [%s, %s]}`, first, second)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceView()
+	cfg := map[string]interface{}{
+		"name": "test",
+		"email_channel": []interface{}{
+			map[string]interface{}{"emails": []interface{}{"a@logdna.com"}},
+			map[string]interface{}{"emails": []interface{}{"b@logdna.com"}},
+		},
+	}
+	d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+	d.SetId("abc")
+
+	diags := resourceViewRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("a1", d.Get("email_channel.0.channelid"), "Server IDs are stored")
+	assert.Equal("b2", d.Get("email_channel.1.channelid"), "Server IDs are stored")
+
+	reversed = true
+	d = rs.Data(d.State())
+	diags = resourceViewRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("a1", d.Get("email_channel.0.channelid"), "Order of the state is kept")
+	assert.Equal([]interface{}{"a@logdna.com"}, d.Get("email_channel.0.emails"), "Channels are matched by ID")
+	assert.Equal("b2", d.Get("email_channel.1.channelid"), "Order of the state is kept")
+
+	diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	assert.True(diff.Empty(), "No diff after the server reordered the channels: %v", diff)
+
+	t.Run("Sends the stored IDs on update", func(t *testing.T) {
+		view := viewRequest{}
+		assert.False(view.CreateRequestBody(d).HasError(), "No errors")
+		assert.Equal("a1", view.Channels[0].AlertID, "ID is sent")
+		assert.Equal("b2", view.Channels[1].AlertID, "ID is sent")
+	})
+}
+
+func TestView_AnomalySettings(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("Validates the ranges", func(t *testing.T) {
+		channel := resourceView().Schema["slack_channel"].Elem.(*schema.Resource).Schema
+		for _, v := range []float64{minZScore, 3, maxZScore} {
+			_, errs := channel["zscore"].ValidateFunc(v, "zscore")
+			assert.Empty(errs, "%v is in range", v)
+		}
+		for _, v := range []float64{0.1, 10.5, -2} {
+			_, errs := channel["zscore"].ValidateFunc(v, "zscore")
+			assert.Len(errs, 1, "%v is out of range", v)
+		}
+		_, errs := channel["sensitivity"].ValidateFunc("medium", "sensitivity")
+		assert.Empty(errs, "Known sensitivity")
+		_, errs = channel["sensitivity"].ValidateFunc("extreme", "sensitivity")
+		assert.Len(errs, 1, "Unknown sensitivity")
+	})
+
+	t.Run("Round-trips the anomaly detection config", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				sent := viewRequest{}
+				assert.Nil(json.NewDecoder(r.Body).Decode(&sent), "No errors")
+				assert.Equal(&anomalyConfig{Sensitivity: "high", ZScore: 2.5}, sent.Channels[0].Anomaly, "Anomaly config is sent")
+				assert.Nil(sent.Channels[1].Anomaly, "Anomaly config is omitted when unset")
+			}
+			fmt.Fprint(w, `{
+				"viewID": "abc",
+				"name": "test",
+				"query": "test",
+				"channels": [
+					{"integration": "email", "emails": ["a@logdna.com"], "operator": "presence", "triggerlimit": 15,
+					 "anomaly": {"sensitivity": "high", "zscore": 2.5}},
+					{"integration": "email", "emails": ["b@logdna.com"], "operator": "presence", "triggerlimit": 15}
+				]
+			}`)
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		rs := resourceView()
+		cfg := map[string]interface{}{
+			"name":  "test",
+			"query": "test",
+			"email_channel": []interface{}{
+				map[string]interface{}{"emails": []interface{}{"a@logdna.com"}, "sensitivity": "high", "zscore": 2.5},
+				map[string]interface{}{"emails": []interface{}{"b@logdna.com"}},
+			},
+		}
+		d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+
+		diags := resourceViewCreate(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal("high", d.Get("email_channel.0.sensitivity"), "sensitivity is read back")
+		assert.Equal(2.5, d.Get("email_channel.0.zscore"), "zscore is read back")
+		assert.Equal(0.0, d.Get("email_channel.1.zscore"), "Unset zscore stays empty")
+
+		diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+		assert.Nil(err, "No errors")
+		assert.True(diff.Empty(), "No diff after refresh: %v", diff)
+	})
+}
+
+func TestView_Match(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Run("Validates the allowed values", func(t *testing.T) {
+		match := resourceView().Schema["match"]
+		for _, v := range viewMatches {
+			_, errs := match.ValidateFunc(v, "match")
+			assert.Empty(errs, "%s is allowed", v)
+		}
+		_, errs := match.ValidateFunc("some", "match")
+		assert.Len(errs, 1, "Unknown values are rejected")
+	})
+
+	t.Run("Round-trips the match", func(t *testing.T) {
+		stored := ""
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" || r.Method == "PUT" {
+				sent := map[string]interface{}{}
+				assert.Nil(json.NewDecoder(r.Body).Decode(&sent), "No errors")
+				stored, _ = sent["match"].(string)
+			}
+			fmt.Fprintf(w, `{"viewID": "abc", "name": "test", "apps": ["foo"], "hosts": ["bar"], "match": %q}`, stored)
+		}))
+		defer ts.Close()
+
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+		rs := resourceView()
+		cfg := map[string]interface{}{
+			"name":  "test",
+			"apps":  []interface{}{"foo"},
+			"hosts": []interface{}{"bar"},
+			"match": "any",
+		}
+		d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+
+		diags := resourceViewCreate(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal("any", stored, "The match is sent")
+		assert.Equal("any", d.Get("match"), "The match is read back")
+
+		diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+		assert.Nil(err, "No errors")
+		assert.True(diff.Empty(), "No diff after refresh: %v", diff)
+
+		delete(cfg, "match")
+		stored = "all"
+		d = schema.TestResourceDataRaw(t, rs.Schema, cfg)
+		d.SetId("abc")
+		diags = resourceViewRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal("all", d.Get("match"), "The default of the API is read")
+
+		diff, err = rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+		assert.Nil(err, "No errors")
+		assert.True(diff.Empty(), "No diff when the match is not configured: %v", diff)
+	})
+}
+
+func TestView_WebhookSecretRotation(t *testing.T) {
+	assert := assert.New(t)
+
+	var sent []channelRequest
+	var stored map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" || r.Method == "PUT" {
+			view := viewRequest{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&view), "No errors")
+			sent = view.Channels
+			stored = view.Channels[0].Headers
+		}
+		headers, _ := json.Marshal(stored)
+		fmt.Fprintf(w, `{
+			"viewID": "abc",
+			"name": "test",
+			"query": "test",
+			"channels": [{
+				"alertid": "wh1",
+				"integration": "webhook",
+				"url": "https://example.com/hook",
+				"method": "post",
+				"operator": "presence",
+				"triggerlimit": 15,
+				"triggerinterval": "30",
+				"headers": %s
+			}]
+		}`, headers)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceView()
+	cfg := func(secret string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":  "test",
+			"query": "test",
+			"webhook_channel": []interface{}{map[string]interface{}{
+				"url":             "https://example.com/hook",
+				"method":          "post",
+				"triggerinterval": "30",
+				"triggerlimit":    15,
+				"headers":         map[string]interface{}{"X-Team": "logs"},
+				"secret":          secret,
+			}},
+		}
+	}
+
+	d := schema.TestResourceDataRaw(t, rs.Schema, cfg("first"))
+	diags := resourceViewCreate(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal(map[string]string{"X-Team": "logs", "Authorization": "first"}, sent[0].Headers, "The secret is sent as a header")
+	assert.Equal("first", d.Get("webhook_channel.0.secret"), "The secret is kept in the state")
+	assert.Equal(map[string]interface{}{"X-Team": "logs"}, d.Get("webhook_channel.0.headers"), "The secret is not stored in headers")
+
+	rotated := cfg("second")
+	diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(rotated), &pc)
+	assert.Nil(err, "No errors")
+	assert.False(diff.RequiresNew(), "The view is not recreated")
+	assert.Len(diff.Attributes, 1, "Only the secret changes: %v", diff.Attributes)
+	assert.Contains(diff.Attributes, "webhook_channel.0.secret", "The secret changes")
+
+	before := sent[0]
+	d = schema.TestResourceDataRaw(t, rs.Schema, rotated)
+	d.SetId("abc")
+	diags = resourceViewUpdate(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("second", sent[0].Headers["Authorization"], "The rotated secret is sent")
+	before.Headers, sent[0].Headers = nil, nil
+	assert.Equal(before, sent[0], "The other channel fields are untouched")
+	assert.Equal("second", d.Get("webhook_channel.0.secret"), "The rotated secret is confirmed")
+
+	diff, err = rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(rotated), &pc)
+	assert.Nil(err, "No errors")
+	assert.True(diff.Empty(), "No diff after the rotation: %v", diff)
+
+	// A secret the API dropped is not reported as applied
+	stored = map[string]string{"X-Team": "logs"}
+	diags = resourceViewRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("", d.Get("webhook_channel.0.secret"), "The missing secret is cleared")
+}
+
+func TestView_IgnoreFields(t *testing.T) {
+	assert := assert.New(t)
+
+	remoteQuery := "level:error"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"viewID": "abc", "name": "test", "query": %q, "hosts": ["changed"]}`, remoteQuery)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceView()
+	cfg := map[string]interface{}{
+		"name":          "test",
+		"query":         "level:error",
+		"hosts":         []interface{}{"bar"},
+		"ignore_fields": []interface{}{"hosts"},
+	}
+	d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+	d.SetId("abc")
+
+	remoteQuery = "level:warn"
+	diags := resourceViewRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal([]interface{}{"bar"}, d.Get("hosts"), "The ignored field keeps its state")
+	assert.Equal("level:warn", d.Get("query"), "The other fields are read")
+
+	remoteQuery = "level:error"
+	diags = resourceViewRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	assert.True(diff.Empty(), "No diff for a server-side change of an ignored field: %v", diff)
+
+	_, errs := rs.Schema["ignore_fields"].Elem.(*schema.Schema).ValidateFunc("viewID", "ignore_fields")
+	assert.Len(errs, 1, "Unknown fields are rejected")
+}
+
+func TestView_ReadDeletedOutOfBand(t *testing.T) {
+	assert := assert.New(t)
+	status := 404
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"error":"Nothing found"}`)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	d := schema.TestResourceDataRaw(t, resourceView().Schema, map[string]interface{}{"name": "test"})
+	d.SetId("abc")
+
+	diags := resourceViewRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("", d.Id(), "The view is removed from the state")
+
+	status = 403
+	d.SetId("abc")
+	diags = resourceViewRead(context.Background(), d, &pc)
+	assert.True(diags.HasError(), "Other errors are reported")
+	assert.Equal("abc", d.Id(), "The view is kept in the state")
+}
+
+func TestView_ChannelIntegrationChange(t *testing.T) {
+	assert := assert.New(t)
+
+	var sent []channelRequest
+	stored := []channelRequest{}
+	nextID := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" || r.Method == "PUT" {
+			view := viewRequest{}
+			assert.Nil(json.NewDecoder(r.Body).Decode(&view), "No errors")
+			sent = view.Channels
+			// Channels sent without ID are created, the others are updated
+			stored = []channelRequest{}
+			for _, c := range view.Channels {
+				if c.AlertID == "" {
+					nextID++
+					c.AlertID = fmt.Sprintf("%s%d", c.Integration, nextID)
+				}
+				stored = append(stored, c)
+			}
+		}
+		channels, _ := json.Marshal(stored)
+		fmt.Fprintf(w, `{"viewID": "abc", "name": "test", "query": "test", "channels": %s}`, channels)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceView()
+	slack := func(url string) map[string]interface{} {
+		return map[string]interface{}{"url": url, "triggerlimit": 15}
+	}
+	cfg := map[string]interface{}{
+		"name":          "test",
+		"query":         "test",
+		"email_channel": []interface{}{map[string]interface{}{"emails": []interface{}{"a@logdna.com"}, "triggerlimit": 15}},
+		"slack_channel": []interface{}{slack("https://hooks.slack.com/first"), slack("https://hooks.slack.com/second")},
+	}
+	d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+	diags := resourceViewCreate(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Equal("slack2", d.Get("slack_channel.0.channelid"), "The IDs are stored")
+	assert.Equal("slack3", d.Get("slack_channel.1.channelid"), "The IDs are stored")
+
+	// The first slack channel becomes a webhook
+	cfg["slack_channel"] = []interface{}{slack("https://hooks.slack.com/second")}
+	cfg["webhook_channel"] = []interface{}{map[string]interface{}{
+		"url":          "https://hooks.slack.com/first",
+		"method":       "post",
+		"triggerlimit": 15,
+	}}
+	diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	assert.False(diff.RequiresNew(), "The view is not recreated")
+
+	state, diags := rs.Apply(context.Background(), d.State(), diff, &pc)
+	assert.False(diags.HasError(), "No errors")
+	assert.Len(sent, 3, "Every channel is sent")
+	assert.Equal("email1", sent[0].AlertID, "The unchanged channel is kept")
+	assert.Equal(SLACK, sent[1].Integration, "The remaining slack channel is sent")
+	assert.Equal("https://hooks.slack.com/second", sent[1].URL, "The remaining slack channel is sent")
+	assert.Empty(sent[1].AlertID, "The shifted channel does not take the ID of the moved one")
+	assert.Equal(WEBHOOK, sent[2].Integration, "The moved channel is sent as a webhook")
+	assert.Empty(sent[2].AlertID, "The moved channel is created")
+
+	d = rs.Data(state)
+	assert.Equal("email1", d.Get("email_channel.0.channelid"), "The unchanged channel keeps its ID")
+	assert.Equal("https://hooks.slack.com/second", d.Get("slack_channel.0.url"), "The slack channel is read back")
+	assert.Equal("https://hooks.slack.com/first", d.Get("webhook_channel.0.url"), "The webhook channel is read back")
+
+	diff, err = rs.Diff(context.Background(), state, terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	assert.True(diff.Empty(), "No diff after the change: %v", diff)
+}
+
+func TestView_CategoryType(t *testing.T) {
+	assert := assert.New(t)
+	lists := map[string]string{
+		"views":   `[{"id": "1", "type": "views", "name": "Demo"}]`,
+		"boards":  `[{"id": "2", "type": "boards", "name": "Dashboards"}]`,
+		"screens": `[]`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list, ok := lists[strings.TrimPrefix(r.URL.Path, "/v1/config/categories/")]
+		if !ok {
+			w.WriteHeader(404)
+			return
+		}
+		fmt.Fprint(w, list)
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceView()
+	diff := func(categories ...interface{}) error {
+		_, err := rs.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":       "test",
+			"query":      "test",
+			"categories": categories,
+		}), &pc)
+		return err
+	}
+
+	assert.EqualError(
+		diff("Demo", "Dashboards"),
+		`category "Dashboards" is a boards category, a logdna_view only accepts views categories`,
+		"Categories of another type are rejected",
+	)
+	assert.Nil(diff("demo"), "Views categories are accepted, case-insensitively")
+	assert.Nil(diff("Unknown"), "Unknown categories are left to the API")
+
+	delete(lists, "views")
+	assert.Nil(diff("Dashboards"), "Categories are not validated when they cannot be listed")
+}
+
+func TestView_NullLists(t *testing.T) {
+	assert := assert.New(t)
+	rs := resourceView()
+
+	for name, lists := range map[string]string{
+		"null":          `"apps": null, "category": null, "hosts": null, "levels": null, "tags": null, "channels": null`,
+		"empty":         `"apps": [], "category": [], "hosts": [], "levels": [], "tags": [], "channels": []`,
+		"null elements": `"apps": [null], "category": [null], "hosts": [null], "levels": [null], "tags": [null], "channels": [null]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"viewID": "abc", "name": "test", "query": "test", %s}`, lists)
+			}))
+			defer ts.Close()
+			pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+			for _, cfg := range []map[string]interface{}{
+				{"name": "test", "query": "test"},
+				{
+					"name":       "test",
+					"query":      "test",
+					"apps":       []interface{}{},
+					"categories": []interface{}{},
+					"hosts":      []interface{}{},
+					"levels":     []interface{}{},
+					"tags":       []interface{}{},
+				},
+			} {
+				d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+				d.SetId("abc")
+				diags := resourceViewRead(context.Background(), d, &pc)
+				assert.False(diags.HasError(), "No errors")
+				assert.Equal([]interface{}{}, d.Get("apps"), "The list is empty")
+
+				diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+				assert.Nil(err, "No errors")
+				assert.True(diff.Empty(), "No diff against %v: %v", cfg, diff)
+			}
+		})
+	}
+}
+
+func TestView_Negations(t *testing.T) {
+	assert := assert.New(t)
+	rs := resourceView()
+
+	var stored viewRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			assert.Nil(json.NewDecoder(r.Body).Decode(&stored), "No errors")
+		}
+		assert.Nil(json.NewEncoder(w).Encode(viewResponse{
+			ViewID: "abc",
+			Name:   stored.Name,
+			Query:  stored.Query,
+			Apps:   stored.Apps,
+			Hosts:  stored.Hosts,
+		}), "No errors")
+	}))
+	defer ts.Close()
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+	cfg := map[string]interface{}{
+		"name":  "test",
+		"query": "level:error",
+		"apps":  []interface{}{"web", "!noisy-app", "api"},
+		"hosts": []interface{}{"!build box"},
+	}
+	d := schema.TestResourceDataRaw(t, rs.Schema, cfg)
+	diags := resourceViewCreate(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+
+	assert.Equal([]string{"web", "api"}, stored.Apps, "Only the matched apps are sent as apps")
+	assert.Empty(stored.Hosts, "Only the matched hosts are sent as hosts")
+	assert.Equal(`level:error -app:noisy-app -host:"build box"`, stored.Query, "The negated entries are excluded by the query")
+
+	assert.Equal([]interface{}{"web", "!noisy-app", "api"}, d.Get("apps"), "The negated apps are read back in place")
+	assert.Equal([]interface{}{"!build box"}, d.Get("hosts"), "The negated hosts are read back")
+	assert.Equal("level:error", d.Get("query"), "The exclusions are stripped from the query")
+	diff, err := rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	assert.True(diff.Empty(), "No diff after the round trip: %v", diff)
+
+	t.Run("Queries edited outside of Terraform are kept", func(t *testing.T) {
+		stored.Query = "level:warn"
+		diags := resourceViewRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal("level:warn", d.Get("query"), "The remote query is read")
+		assert.Equal([]interface{}{"web", "api"}, d.Get("apps"), "The exclusions are no longer applied")
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		_, err := rs.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":  "test",
+			"query": "test",
+			"match": "any",
+			"apps":  []interface{}{"web", "!noisy-app"},
+		}), &pc)
+		assert.EqualError(err, `apps cannot negate !noisy-app when match is "any", which would match every other line`)
+
+		_, errs := validateViewEntry("! ", "hosts.0")
+		assert.Len(errs, 1, "A negation needs a name")
+		_, errs = validateViewEntry("!noisy-app", "apps.0")
+		assert.Empty(errs, "Negations are accepted")
+	})
+}
+
+// TestView_ImportChannelFidelity imports a view with one channel of each
+// integration, which the API saved with its own defaults, and expects the
+// configuration which created it to plan no change
+func TestView_ImportChannelFidelity(t *testing.T) {
+	assert := assert.New(t)
+	var saved map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			assert.Nil(json.NewDecoder(r.Body).Decode(&saved), "No errors")
+			saved["viewID"] = "abc"
+			for i, c := range saved["channels"].([]interface{}) {
+				channel := c.(map[string]interface{})
+				channel["alertid"] = fmt.Sprintf("alert-%d", i)
+				channel["immediate"] = channel["immediate"] == "true"
+				channel["terminal"] = channel["terminal"] == "true"
+				channel["triggerinterval"] = 900
+				if channel["integration"] == WEBHOOK {
+					channel["method"] = "POST"
+					channel["headers"] = map[string]string{}
+				}
+			}
+		}
+		assert.Nil(json.NewEncoder(w).Encode(saved), "No errors")
+	}))
+	defer ts.Close()
+
+	pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+	rs := resourceView()
+	cfg := map[string]interface{}{
+		"name":  "test",
+		"query": "level:error",
+		"email_channel": []interface{}{
+			map[string]interface{}{"emails": []interface{}{"test@logdna.com"}, "triggerinterval": "15m"},
+		},
+		"pagerduty_channel": []interface{}{
+			map[string]interface{}{"key": "Your PagerDuty API key goes here", "triggerinterval": "15m"},
+		},
+		"slack_channel": []interface{}{
+			map[string]interface{}{"url": "https://hooks.slack.com/services/identifier/secret", "triggerinterval": "15m"},
+		},
+		"webhook_channel": []interface{}{
+			map[string]interface{}{"url": "https://yourwebhook/endpoint", "triggerinterval": "15m"},
+		},
+	}
+
+	diff, err := rs.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	_, diags := rs.Apply(context.Background(), nil, diff, &pc)
+	assert.False(diags.HasError(), "No errors")
+
+	imported, err := rs.Importer.StateContext(context.Background(), rs.Data(&terraform.InstanceState{ID: "abc"}), &pc)
+	assert.Nil(err, "No errors")
+	d := rs.Data(imported[0].State())
+	diags = resourceViewRead(context.Background(), d, &pc)
+	assert.False(diags.HasError(), "No errors")
+	for _, integration := range []string{EMAIL, PAGERDUTY, SLACK, WEBHOOK} {
+		assert.NotEmpty(d.Get(integration+"_channel.0.channelid"), "%s channel is imported", integration)
+	}
+	assert.Equal("POST", d.Get("webhook_channel.0.method"), "Method saved by the API is stored")
+
+	diff, err = rs.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(cfg), &pc)
+	assert.Nil(err, "No errors")
+	assert.True(diff.Empty(), "No diff after the import: %v", diff)
+}
+
+// TestView_ImportMixedChannels only sets the required arguments of the
+// channels, leaving the others to the defaults of the API. The import step
+// verifies the imported channels match the applied ones, which planned no
+// change.
+func TestView_ImportMixedChannels(t *testing.T) {
+	chArgs := map[string]map[string]string{
+		"email": {
+			"emails":          `["test@logdna.com"]`,
+			"triggerinterval": `"15m"`,
+		},
+		"pagerduty": {
+			"key":             `"Your PagerDuty API key goes here"`,
+			"triggerinterval": `"15m"`,
+		},
+		"slack": {
+			"url":             `"https://hooks.slack.com/services/identifier/secret"`,
+			"triggerinterval": `"15m"`,
+		},
+		"webhook": {
+			"url":             `"https://yourwebhook/endpoint"`,
+			"triggerinterval": `"15m"`,
+		},
+	}
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmtTestConfigResource("view", "mixed", globalPcArgs, viewDefaults, chArgs, nilLst),
+				Check: resource.ComposeTestCheckFunc(
+					testResourceExists("view", "mixed"),
+					resource.TestCheckResourceAttr("logdna_view.mixed", "email_channel.#", "1"),
+					resource.TestCheckResourceAttr("logdna_view.mixed", "pagerduty_channel.#", "1"),
+					resource.TestCheckResourceAttr("logdna_view.mixed", "slack_channel.#", "1"),
+					resource.TestCheckResourceAttr("logdna_view.mixed", "webhook_channel.#", "1"),
+				),
+			},
+			{
+				ResourceName:      "logdna_view.mixed",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
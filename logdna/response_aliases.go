@@ -0,0 +1,82 @@
+package logdna
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Fields which the API returned under other names across versions, keyed by
+// the name of the field in the response types. Keys which only differ in case,
+// e.g. presetId, need no alias: encoding/json matches them already.
+var (
+	viewResponseAliases = map[string][]string{
+		"category":  {"categories"},
+		"presetids": {"preset_ids"},
+		"viewID":    {"view_id"},
+	}
+	alertResponseAliases = map[string][]string{
+		"presetid": {"preset_id"},
+	}
+)
+
+// withAliases renames the aliased keys of a JSON object to their field name,
+// unless the object also has the field itself. Other values are returned as is.
+func withAliases(b []byte, aliases map[string][]string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil || fields == nil {
+		// Left for the decoding of the response type to report
+		return b, nil
+	}
+
+	renamed := false
+	for field, names := range aliases {
+		if _, ok := lookupKey(fields, field); ok {
+			continue
+		}
+		for _, name := range names {
+			if key, ok := lookupKey(fields, name); ok {
+				fields[field] = fields[key]
+				delete(fields, key)
+				renamed = true
+				break
+			}
+		}
+	}
+	if !renamed {
+		return b, nil
+	}
+	return json.Marshal(fields)
+}
+
+// lookupKey finds a key case-insensitively, like encoding/json does
+func lookupKey(fields map[string]json.RawMessage, name string) (string, bool) {
+	if _, ok := fields[name]; ok {
+		return name, true
+	}
+	for key := range fields {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func (view *viewResponse) UnmarshalJSON(b []byte) error {
+	// plain has the fields of viewResponse without this method
+	type plain viewResponse
+	b, err := withAliases(b, viewResponseAliases)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, (*plain)(view))
+}
+
+func (alert *alertResponse) UnmarshalJSON(b []byte) error {
+	// plain has the fields of alertResponse without this method
+	type plain alertResponse
+	b, err := withAliases(b, alertResponseAliases)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, (*plain)(alert))
+}
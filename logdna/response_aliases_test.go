@@ -0,0 +1,51 @@
+package logdna
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseAliases_View(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, raw := range []string{
+		`{"viewID": "abc", "category": ["Demo"], "presetids": ["p1"]}`,
+		`{"viewId": "abc", "Category": ["Demo"], "presetIds": ["p1"]}`,
+		`{"view_id": "abc", "categories": ["Demo"], "preset_ids": ["p1"]}`,
+	} {
+		view := viewResponse{}
+		assert.Nil(json.Unmarshal([]byte(raw), &view), "No errors for %s", raw)
+		assert.Equal("abc", string(view.ViewID), "ViewID of %s", raw)
+		assert.Equal(flexStrings{"Demo"}, view.Category, "Category of %s", raw)
+		assert.Equal([]string{"p1"}, view.presetIDs(), "PresetIds of %s", raw)
+	}
+
+	view := viewResponse{}
+	assert.Nil(json.Unmarshal([]byte(`{"viewID": "abc", "view_id": "old"}`), &view), "No errors")
+	assert.Equal("abc", string(view.ViewID), "The field wins over its alias")
+
+	assert.Error(json.Unmarshal([]byte(`{"view_id": {}}`), &viewResponse{}), "Aliased values are still validated")
+	assert.Error(json.Unmarshal([]byte(`["abc"]`), &viewResponse{}), "Other values are rejected")
+}
+
+func TestResponseAliases_Alert(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, raw := range []string{
+		`{"presetid": "p1", "name": "test"}`,
+		`{"presetId": "p1", "name": "test"}`,
+		`{"preset_id": "p1", "name": "test"}`,
+	} {
+		alert := alertResponse{}
+		assert.Nil(json.Unmarshal([]byte(raw), &alert), "No errors for %s", raw)
+		assert.Equal("p1", string(alert.PresetID), "PresetID of %s", raw)
+		assert.Equal("test", alert.Name, "Other fields of %s", raw)
+	}
+
+	alert := alertResponse{}
+	assert.Nil(json.Unmarshal([]byte(`{"preset_id": "p1", "channels": [{"integration": "email", "emails": ["a@logdna.com"]}]}`), &alert), "No errors")
+	assert.Equal("p1", string(alert.PresetID), "The alias is decoded")
+	assert.Len(alert.Channels, 1, "The channels are decoded along")
+}
@@ -5,62 +5,325 @@ package logdna
 // returned by the GET. In a perfect world, they would use the same types.
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 )
 
 type viewResponse struct {
-	Apps      []string          `json:"apps,omitempty"`
-	Category  []string          `json:"category,omitempty"`
-	Channels  []channelResponse `json:"channels,omitempty"`
-	Error     string            `json:"error,omitempty"`
-	Hosts     []string          `json:"hosts,omitempty"`
-	Levels    []string          `json:"levels,omitempty"`
-	Name      string            `json:"name,omitempty"`
-	Query     string            `json:"query,omitempty"`
-	Tags      []string          `json:"tags,omitempty"`
-	PresetIds []string          `json:"presetids,omitempty"`
-	ViewID    string            `json:"viewID"`
+	Apps      flexStrings `json:"apps,omitempty"`
+	Category  flexStrings `json:"category,omitempty"`
+	Channels  Channels    `json:"channels,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Hosts     flexStrings `json:"hosts,omitempty"`
+	Levels    flexStrings `json:"levels,omitempty"`
+	Match     string      `json:"match,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	Query     string      `json:"query,omitempty"`
+	Tags      flexStrings `json:"tags,omitempty"`
+	PresetIds []flexID    `json:"presetids,omitempty"`
+	ViewID    flexID      `json:"viewID"`
 }
 
 type alertResponse struct {
-	Name     string            `json:"name,omitempty"`
-	Channels []channelResponse `json:"channels,omitempty"`
-	PresetID string            `json:"presetid"`
+	Name     string   `json:"name,omitempty"`
+	Channels Channels `json:"channels,omitempty"`
+	PresetID flexID   `json:"presetid"`
 }
 
 type keyResponse struct {
-	KeyID   string `json:"id"`
+	KeyID   flexID `json:"id"`
 	Key     string `json:"key"`
 	Name    string `json:"name"`
 	Type    string `json:"type"`
 	Created int    `json:"created,omitempty"`
 }
 
+type memberResponse struct {
+	Email  string      `json:"email"`
+	Role   string      `json:"role"`
+	Groups flexStrings `json:"groups"`
+}
+
+type groupResponse struct {
+	ID   flexID `json:"id"`
+	Name string `json:"name"`
+}
+
+type accountResponse struct {
+	Plan     string          `json:"plan"`
+	Tier     string          `json:"tier"`
+	Features map[string]bool `json:"features"`
+}
+
 // channelResponse contains channel data returned from the logdna APIs
 // NOTE - Properties with `interface` are due to the APIs returning
 // some things as strings (PUT/emails) and other times arrays (GET/emails)
 type channelResponse struct {
-	AlertID         string            `json:"alertid,omitempty"`
+	Active          *flexBool         `json:"active,omitempty"`
+	AlertID         flexID            `json:"alertid,omitempty"`
+	Anomaly         *anomalyConfig    `json:"anomaly,omitempty"`
 	BodyTemplate    string            `json:"bodyTemplate,omitempty"`
 	Emails          interface{}       `json:"emails,omitempty"`
 	Headers         map[string]string `json:"headers,omitempty"`
-	Immediate       bool              `json:"immediate,omitempty"`
+	Immediate       flexBool          `json:"immediate,omitempty"`
 	Integration     string            `json:"integration,omitempty"`
 	Key             string            `json:"key,omitempty"`
 	Method          string            `json:"method,omitempty"`
 	Operator        string            `json:"operator,omitempty"`
-	Terminal        bool              `json:"terminal,omitempty"`
-	TriggerInterval interface{}       `json:"triggerinterval,omitempty"`
+	Terminal        flexBool          `json:"terminal,omitempty"`
+	TriggerInterval intervalDuration  `json:"triggerinterval,omitempty"`
 	TriggerLimit    int               `json:"triggerlimit,omitempty"`
 	Timezone        string            `json:"timezone,omitempty"`
 	URL             string            `json:"url,omitempty"`
 }
 
+// Channels are the channels of a view or an alert. The elements of the array
+// differ by integration: each one is decoded into the struct of its
+// integration, so that a field is only read for the integrations it belongs to.
+type Channels []channelResponse
+
+func (channels *Channels) UnmarshalJSON(b []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(b, &raws); err != nil {
+		return err
+	}
+
+	decoded := make(Channels, 0, len(raws))
+	for i, raw := range raws {
+		if string(raw) == "null" {
+			continue
+		}
+		discriminator := struct {
+			Integration string `json:"integration"`
+		}{}
+		if err := json.Unmarshal(raw, &discriminator); err != nil {
+			return fmt.Errorf("channel %d: %w", i, err)
+		}
+
+		// Unsupported integrations keep the common fields, to be reported
+		var c integrationChannel = &channelCommon{}
+		if newChannel, ok := integrationChannels[discriminator.Integration]; ok {
+			c = newChannel()
+		}
+		if err := json.Unmarshal(raw, c); err != nil {
+			return fmt.Errorf("%s channel %d: %w", discriminator.Integration, i, err)
+		}
+		decoded = append(decoded, c.response())
+	}
+	*channels = decoded
+	return nil
+}
+
+// integrationChannel is a channel decoded into the struct of its integration
+type integrationChannel interface {
+	response() channelResponse
+}
+
+var integrationChannels = map[string]func() integrationChannel{
+	EMAIL:     func() integrationChannel { return &emailChannel{} },
+	PAGERDUTY: func() integrationChannel { return &pagerDutyChannel{} },
+	SLACK:     func() integrationChannel { return &slackChannel{} },
+	WEBHOOK:   func() integrationChannel { return &webhookChannel{} },
+}
+
+// channelCommon are the fields shared by every integration
+type channelCommon struct {
+	Active          *flexBool        `json:"active,omitempty"`
+	AlertID         flexID           `json:"alertid,omitempty"`
+	Anomaly         *anomalyConfig   `json:"anomaly,omitempty"`
+	Immediate       flexBool         `json:"immediate,omitempty"`
+	Integration     string           `json:"integration,omitempty"`
+	Operator        string           `json:"operator,omitempty"`
+	Terminal        flexBool         `json:"terminal,omitempty"`
+	TriggerInterval intervalDuration `json:"triggerinterval,omitempty"`
+	TriggerLimit    int              `json:"triggerlimit,omitempty"`
+}
+
+func (c *channelCommon) response() channelResponse {
+	return channelResponse{
+		Active:          c.Active,
+		AlertID:         c.AlertID,
+		Anomaly:         c.Anomaly,
+		Immediate:       c.Immediate,
+		Integration:     c.Integration,
+		Operator:        c.Operator,
+		Terminal:        c.Terminal,
+		TriggerInterval: c.TriggerInterval,
+		TriggerLimit:    c.TriggerLimit,
+	}
+}
+
+type emailChannel struct {
+	channelCommon
+	Emails   interface{} `json:"emails,omitempty"`
+	Timezone string      `json:"timezone,omitempty"`
+}
+
+func (c *emailChannel) response() channelResponse {
+	r := c.channelCommon.response()
+	r.Emails = c.Emails
+	r.Timezone = c.Timezone
+	return r
+}
+
+type pagerDutyChannel struct {
+	channelCommon
+	Key string `json:"key,omitempty"`
+}
+
+func (c *pagerDutyChannel) response() channelResponse {
+	r := c.channelCommon.response()
+	r.Key = c.Key
+	return r
+}
+
+type slackChannel struct {
+	channelCommon
+	URL string `json:"url,omitempty"`
+}
+
+func (c *slackChannel) response() channelResponse {
+	r := c.channelCommon.response()
+	r.URL = c.URL
+	return r
+}
+
+type webhookChannel struct {
+	channelCommon
+	BodyTemplate string            `json:"bodyTemplate,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Method       string            `json:"method,omitempty"`
+	URL          string            `json:"url,omitempty"`
+}
+
+func (c *webhookChannel) response() channelResponse {
+	r := c.channelCommon.response()
+	r.BodyTemplate = c.BodyTemplate
+	r.Headers = c.Headers
+	r.Method = c.Method
+	r.URL = c.URL
+	return r
+}
+
+// intervalDuration is a trigger interval returned either as a duration string
+// ("5m") or as a number of seconds (300). It always holds the string form.
+type intervalDuration string
+
+func (i *intervalDuration) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*i = intervalDuration(str)
+		return nil
+	}
+
+	var seconds int
+	if err := json.Unmarshal(b, &seconds); err != nil {
+		return fmt.Errorf("interval must be a duration string or a number of seconds, got: %s", b)
+	}
+	*i = intervalDuration(formatIntervalSeconds(seconds))
+	return nil
+}
+
+// formatIntervalSeconds uses the largest unit the seconds divide evenly into,
+// e.g. 3600 is "1h" and 300 is "5m". Other values are bare seconds, e.g. "30",
+// the form the schema accepts for sub-minute intervals.
+func formatIntervalSeconds(seconds int) string {
+	switch {
+	case seconds != 0 && seconds%3600 == 0:
+		return fmt.Sprintf("%dh", seconds/3600)
+	case seconds != 0 && seconds%60 == 0:
+		return fmt.Sprintf("%dm", seconds/60)
+	default:
+		return strconv.Itoa(seconds)
+	}
+}
+
+// flexBool is a flag returned either as a boolean, as a string ("true") or
+// as a number (1)
+type flexBool bool
+
+func (f *flexBool) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case bool:
+		*f = flexBool(t)
+		return nil
+	case float64:
+		if t == 0 || t == 1 {
+			*f = t == 1
+			return nil
+		}
+	case string:
+		if parsed, err := strconv.ParseBool(t); err == nil {
+			*f = flexBool(parsed)
+			return nil
+		}
+	}
+	return fmt.Errorf("flag must be a boolean, \"true\"/\"false\" or 1/0, got: %s", b)
+}
+
+// flexID is an identifier returned either as a string or as a number. It is
+// always stored as a string in the state.
+type flexID string
+
+func (id *flexID) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*id = flexID(str)
+		return nil
+	}
+
+	var num json.Number
+	if err := json.Unmarshal(b, &num); err != nil {
+		return fmt.Errorf("id must be a string or a number, got: %s", b)
+	}
+	*id = flexID(num.String())
+	return nil
+}
+
+// flexStrings is a list of strings which the API may return as null instead of
+// an empty list, or with null entries. Both decode to an empty slice or are
+// dropped, so that reads produce no diff against an empty list in the config.
+type flexStrings []string
+
+func (list *flexStrings) UnmarshalJSON(b []byte) error {
+	var entries []*string
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+
+	decoded := make(flexStrings, 0, len(entries))
+	for _, entry := range entries {
+		if entry != nil {
+			decoded = append(decoded, *entry)
+		}
+	}
+	*list = decoded
+	return nil
+}
+
 type archiveResponse struct {
 	Integration        string `json:"integration"`
+	Format             string `json:"format,omitempty"`
+	Compression        string `json:"compression,omitempty"`
 	Bucket             string `json:"bucket,omitempty"`
 	Endpoint           string `json:"endpoint,omitempty"`
 	APIKey             string `json:"apikey,omitempty"`
@@ -81,17 +344,42 @@ type archiveResponse struct {
 type categoryResponse struct {
 	Name string `json:"name,omitempty"`
 	Type string `json:"type,omitempty"`
-	Id   string `json:"id"`
+	Id   flexID `json:"id"`
+}
+
+// anomaly is never nil, channels without anomaly detection have zero values
+func (channel *channelResponse) anomaly() anomalyConfig {
+	if channel.Anomaly == nil {
+		return anomalyConfig{}
+	}
+	return *channel.Anomaly
+}
+
+// enabled defaults to "true" since channels created before the active flag
+// existed are not returned with one
+func (channel *channelResponse) enabled() string {
+	if channel.Active == nil {
+		return "true"
+	}
+	return strconv.FormatBool(bool(*channel.Active))
+}
+
+func (view *viewResponse) presetIDs() []string {
+	ids := make([]string, 0, len(view.PresetIds))
+	for _, id := range view.PresetIds {
+		ids = append(ids, string(id))
+	}
+	return ids
 }
 
 func (view *viewResponse) MapChannelsToSchema() (map[string][]interface{}, diag.Diagnostics) {
-	channels := view.Channels
+	channels := []channelResponse(view.Channels)
 	channelIntegrations, diags := mapAllChannelsToSchema("view", &channels)
 	return channelIntegrations, *diags
 }
 
 func (alert *alertResponse) MapChannelsToSchema() (map[string][]interface{}, diag.Diagnostics) {
-	channels := alert.Channels
+	channels := []channelResponse(alert.Channels)
 	channelIntegrations, diags := mapAllChannelsToSchema("alert", &channels)
 	return channelIntegrations, *diags
 }
@@ -150,12 +438,16 @@ func mapChannelEmail(channel *channelResponse) map[string]interface{} {
 	c := make(map[string]interface{})
 
 	c["emails"] = channel.Emails
-	c["immediate"] = strconv.FormatBool(channel.Immediate)
+	c["channelid"] = string(channel.AlertID)
+	c["enabled"] = channel.enabled()
+	c["sensitivity"] = channel.anomaly().Sensitivity
+	c["zscore"] = channel.anomaly().ZScore
+	c["immediate"] = strconv.FormatBool(bool(channel.Immediate))
 	c["operator"] = channel.Operator
-	c["terminal"] = strconv.FormatBool(channel.Terminal)
+	c["terminal"] = strconv.FormatBool(bool(channel.Terminal))
 	c["timezone"] = channel.Timezone
 	c["triggerlimit"] = channel.TriggerLimit
-	c["triggerinterval"] = channel.TriggerInterval
+	c["triggerinterval"] = string(channel.TriggerInterval)
 
 	return c
 }
@@ -163,12 +455,16 @@ func mapChannelEmail(channel *channelResponse) map[string]interface{} {
 func mapChannelPagerDuty(channel *channelResponse) map[string]interface{} {
 	c := make(map[string]interface{})
 
-	c["immediate"] = strconv.FormatBool(channel.Immediate)
+	c["channelid"] = string(channel.AlertID)
+	c["enabled"] = channel.enabled()
+	c["sensitivity"] = channel.anomaly().Sensitivity
+	c["zscore"] = channel.anomaly().ZScore
+	c["immediate"] = strconv.FormatBool(bool(channel.Immediate))
 	c["key"] = channel.Key
 	c["operator"] = channel.Operator
-	c["terminal"] = strconv.FormatBool(channel.Terminal)
+	c["terminal"] = strconv.FormatBool(bool(channel.Terminal))
 	c["triggerlimit"] = channel.TriggerLimit
-	c["triggerinterval"] = channel.TriggerInterval
+	c["triggerinterval"] = string(channel.TriggerInterval)
 
 	return c
 }
@@ -176,11 +472,15 @@ func mapChannelPagerDuty(channel *channelResponse) map[string]interface{} {
 func mapChannelSlack(channel *channelResponse) map[string]interface{} {
 	c := make(map[string]interface{})
 
-	c["immediate"] = strconv.FormatBool(channel.Immediate)
+	c["channelid"] = string(channel.AlertID)
+	c["enabled"] = channel.enabled()
+	c["sensitivity"] = channel.anomaly().Sensitivity
+	c["zscore"] = channel.anomaly().ZScore
+	c["immediate"] = strconv.FormatBool(bool(channel.Immediate))
 	c["operator"] = channel.Operator
-	c["terminal"] = strconv.FormatBool(channel.Terminal)
+	c["terminal"] = strconv.FormatBool(bool(channel.Terminal))
 	c["triggerlimit"] = channel.TriggerLimit
-	c["triggerinterval"] = channel.TriggerInterval
+	c["triggerinterval"] = string(channel.TriggerInterval)
 	c["url"] = channel.URL
 
 	return c
@@ -191,17 +491,153 @@ func mapChannelWebhook(channel *channelResponse) map[string]interface{} {
 
 	c["bodytemplate"] = channel.BodyTemplate
 	c["headers"] = channel.Headers
-	c["immediate"] = strconv.FormatBool(channel.Immediate)
+	c["channelid"] = string(channel.AlertID)
+	c["enabled"] = channel.enabled()
+	c["sensitivity"] = channel.anomaly().Sensitivity
+	c["zscore"] = channel.anomaly().ZScore
+	c["immediate"] = strconv.FormatBool(bool(channel.Immediate))
 	c["method"] = channel.Method
 	c["operator"] = channel.Operator
-	c["terminal"] = strconv.FormatBool(channel.Terminal)
+	c["terminal"] = strconv.FormatBool(bool(channel.Terminal))
 	c["triggerlimit"] = channel.TriggerLimit
-	c["triggerinterval"] = channel.TriggerInterval
+	c["triggerinterval"] = string(channel.TriggerInterval)
 	c["url"] = channel.URL
 
 	return c
 }
 
+// orderChannelsByID keeps the channels of an integration in the order of the
+// prior state, matched by their server-generated IDs, so that the API
+// reordering them does not produce a diff. Prior channels without an ID, e.g.
+// right after a create, are matched by their recipient instead. New channels
+// are appended.
+func orderChannelsByID(prior []interface{}, fetched []interface{}) []interface{} {
+	byID := make(map[string]int, len(fetched))
+	for i, c := range fetched {
+		if id := c.(map[string]interface{})["channelid"].(string); id != "" {
+			byID[id] = i
+		}
+	}
+
+	ordered := make([]interface{}, 0, len(fetched))
+	matched := make([]bool, len(fetched))
+	for _, p := range prior {
+		pm, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		i, ok := byID[stringValue(pm["channelid"])]
+		if !ok && stringValue(pm["channelid"]) == "" {
+			i, ok = matchChannelRecipient(pm, fetched, matched)
+		}
+		if ok && !matched[i] {
+			ordered = append(ordered, fetched[i])
+			matched[i] = true
+		}
+	}
+	for i, c := range fetched {
+		if !matched[i] {
+			ordered = append(ordered, c)
+		}
+	}
+	return ordered
+}
+
+// matchChannelRecipient returns the index of the first channel of fetched not
+// matched yet which notifies the same recipient as channel
+func matchChannelRecipient(channel map[string]interface{}, fetched []interface{}, matched []bool) (int, bool) {
+	recipient := channelRecipient(channel)
+	if recipient == "" {
+		return 0, false
+	}
+	for i, c := range fetched {
+		if !matched[i] && channelRecipient(c.(map[string]interface{})) == recipient {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// channelRecipient identifies who a channel notifies: its emails, PagerDuty
+// key or URL depending on the integration
+func channelRecipient(channel map[string]interface{}) string {
+	if url := stringValue(channel["url"]); url != "" {
+		return url
+	}
+	if key := stringValue(channel["key"]); key != "" {
+		return key
+	}
+	switch emails := channel["emails"].(type) {
+	case string:
+		return emails
+	case []string:
+		return strings.Join(emails, ",")
+	case []interface{}:
+		return strings.Join(listToStrings(emails), ",")
+	}
+	return ""
+}
+
+// stringValue returns v when it is a string, or an empty string
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// defaultWebhookSecretHeader carries the secret of a webhook channel
+const defaultWebhookSecretHeader = "Authorization"
+
+// keepWebhookSecrets restores the write-only secrets of webhook channels from
+// the prior state, matched by channel ID or by position for channels not yet
+// created. The secret is kept only while its header is still set remotely, so
+// a secret which was not stored shows up as a diff; the header is left out of
+// headers.
+func keepWebhookSecrets(prior []interface{}, fetched []interface{}) []interface{} {
+	priorByID := make(map[string]map[string]interface{}, len(prior))
+	for _, p := range prior {
+		if pm, ok := p.(map[string]interface{}); ok {
+			if id, _ := pm["channelid"].(string); id != "" {
+				priorByID[id] = pm
+			}
+		}
+	}
+
+	for i, c := range fetched {
+		channel := c.(map[string]interface{})
+		channel["secret"] = ""
+		channel["secretheader"] = defaultWebhookSecretHeader
+
+		p, ok := priorByID[channel["channelid"].(string)]
+		if !ok && i < len(prior) {
+			p, ok = prior[i].(map[string]interface{})
+			ok = ok && p["channelid"] == ""
+		}
+		if !ok {
+			continue
+		}
+		header, _ := p["secretheader"].(string)
+		if header != "" {
+			channel["secretheader"] = header
+		}
+		secret, _ := p["secret"].(string)
+		headers, _ := channel["headers"].(map[string]string)
+		if _, ok := headers[header]; !ok || secret == "" {
+			continue
+		}
+
+		// The API may mask the value, the presence of the header confirms the write
+		withoutSecret := make(map[string]string, len(headers))
+		for k, v := range headers {
+			if k != header {
+				withoutSecret[k] = v
+			}
+		}
+		channel["headers"] = withoutSecret
+		channel["secret"] = secret
+	}
+	return fetched
+}
+
 func appendError(err error, diags *diag.Diagnostics) *diag.Diagnostics {
 	if err != nil {
 		*diags = append(*diags, diag.Diagnostic{
@@ -212,3 +648,13 @@ func appendError(err error, diags *diag.Diagnostics) *diag.Diagnostics {
 	}
 	return diags
 }
+
+// unavailableFeatureWarning is used by data sources in place of an error when
+// the endpoint of a feature is missing from the account's plan
+func unavailableFeatureWarning(feature string, err error) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("The %s feature is not available for this account, an empty result is returned", feature),
+		Detail:   err.Error(),
+	}
+}
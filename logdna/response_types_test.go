@@ -1,6 +1,7 @@
 package logdna
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
@@ -51,3 +52,208 @@ func TestResponseTypes_appendError(t *testing.T) {
 		assert.Equal("Some Error", result.Detail, "Detail")
 	})
 }
+
+func TestResponseTypes_intervalDuration(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string]intervalDuration{
+		`{"triggerinterval":"5m"}`: "5m",
+		`{"triggerinterval":"30"}`: "30",
+		`{"triggerinterval":300}`:  "5m",
+		`{"triggerinterval":3600}`: "1h",
+		`{"triggerinterval":30}`:   "30",
+		`{"triggerinterval":45}`:   "45",
+		`{"triggerinterval":null}`: "",
+		`{"integration":"email"}`:  "",
+	}
+	for body, expected := range cases {
+		c := channelResponse{}
+		err := json.Unmarshal([]byte(body), &c)
+		assert.Nil(err, "No errors for %s", body)
+		assert.Equal(expected, c.TriggerInterval, "Decoded interval for %s", body)
+	}
+
+	t.Run("Rejects values that are neither strings nor seconds", func(t *testing.T) {
+		c := channelResponse{}
+		err := json.Unmarshal([]byte(`{"triggerinterval":{"minutes":5}}`), &c)
+		assert.Error(err, "Expected error")
+		assert.Contains(err.Error(), "interval must be a duration string or a number of seconds")
+	})
+
+	t.Run("Maps integer intervals to the schema as strings", func(t *testing.T) {
+		c := channelResponse{}
+		assert.Nil(json.Unmarshal([]byte(`{"integration":"slack","triggerinterval":900}`), &c), "No errors")
+		assert.Equal("15m", mapChannelSlack(&c)["triggerinterval"], "Interval is a string")
+	})
+}
+
+func TestResponseTypes_channelEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	view := viewResponse{}
+	err := json.Unmarshal([]byte(`{"channels":[
+		{"integration":"email","emails":["a@logdna.com"],"active":false},
+		{"integration":"email","emails":["b@logdna.com"],"active":true},
+		{"integration":"email","emails":["c@logdna.com"]}
+	]}`), &view)
+	assert.Nil(err, "No errors")
+
+	integrations, diags := view.MapChannelsToSchema()
+	assert.Empty(diags, "No diags")
+
+	emails := integrations[EMAIL]
+	assert.Equal("false", emails[0].(map[string]interface{})["enabled"], "Inactive channel is disabled")
+	assert.Equal("true", emails[1].(map[string]interface{})["enabled"], "Active channel is enabled")
+	assert.Equal("true", emails[2].(map[string]interface{})["enabled"], "Channels without the flag are enabled")
+}
+
+func TestResponseTypes_flexBool(t *testing.T) {
+	assert := assert.New(t)
+
+	for raw, expected := range map[string]bool{
+		`{"immediate":true,"terminal":false,"active":true}`:        true,
+		`{"immediate":false,"terminal":true,"active":false}`:       false,
+		`{"immediate":"true","terminal":"false","active":"true"}`:  true,
+		`{"immediate":"false","terminal":"true","active":"false"}`: false,
+		`{"immediate":1,"terminal":0,"active":1}`:                  true,
+		`{"immediate":0,"terminal":1,"active":0}`:                  false,
+		`{"immediate":null,"terminal":true,"active":null}`:         false,
+		`{"immediate":"TRUE","terminal":"0","active":"1"}`:         true,
+	} {
+		channel := channelResponse{}
+		assert.Nil(json.Unmarshal([]byte(raw), &channel), "No errors for %s", raw)
+		assert.Equal(expected, bool(channel.Immediate), "immediate of %s", raw)
+		assert.Equal(!expected, bool(channel.Terminal), "terminal of %s", raw)
+	}
+
+	channel := channelResponse{}
+	assert.Nil(json.Unmarshal([]byte(`{"active":0}`), &channel), "No errors")
+	assert.Equal("false", channel.enabled(), "Numeric flags map to the schema")
+
+	rule := exclusionRule{}
+	assert.Nil(json.Unmarshal([]byte(`{"active":1}`), &rule), "No errors")
+	assert.True(bool(rule.Active), "Exclusion rules accept numeric flags")
+	body, err := json.Marshal(rule)
+	assert.Nil(err, "No errors")
+	assert.Contains(string(body), `"active":true`, "Flags are sent as booleans")
+
+	for _, raw := range []string{`{"immediate":2}`, `{"immediate":"yes"}`, `{"immediate":[]}`} {
+		assert.Error(json.Unmarshal([]byte(raw), &channelResponse{}), "%s is rejected", raw)
+	}
+}
+
+func TestResponseTypes_flexID(t *testing.T) {
+	assert := assert.New(t)
+
+	for raw, expected := range map[string]string{
+		`{"viewID":"5f1e0d4a3b","presetids":["abc"]}`: "5f1e0d4a3b",
+		`{"viewID":12345,"presetids":[678]}`:          "12345",
+		`{"viewID":"12345","presetids":["678"]}`:      "12345",
+		`{"viewID":null}`:                             "",
+	} {
+		view := viewResponse{}
+		assert.Nil(json.Unmarshal([]byte(raw), &view), "No errors for %s", raw)
+		assert.Equal(expected, string(view.ViewID), "ID of %s", raw)
+	}
+
+	view := viewResponse{}
+	assert.Nil(json.Unmarshal([]byte(`{"viewID":1,"presetids":[678]}`), &view), "No errors")
+	assert.Equal([]string{"678"}, view.presetIDs(), "Numeric preset IDs are strings")
+
+	rule := exclusionRule{}
+	assert.Nil(json.Unmarshal([]byte(`{"id":9007199254740993}`), &rule), "No errors")
+	assert.Equal("9007199254740993", string(rule.ID), "Large numeric IDs keep their precision")
+
+	assert.Error(json.Unmarshal([]byte(`{"viewID":{}}`), &viewResponse{}), "Objects are rejected")
+}
+
+func TestResponseTypes_flexStrings(t *testing.T) {
+	assert := assert.New(t)
+
+	for raw, expected := range map[string]flexStrings{
+		`{"apps":null,"channels":null}`:                            {},
+		`{"apps":[],"channels":[]}`:                                {},
+		`{"apps":[null,"app",null],"channels":[null]}`:             {"app"},
+		`{"apps":["app","other"],"channels":[{"integration":""}]}`: {"app", "other"},
+	} {
+		view := viewResponse{}
+		assert.Nil(json.Unmarshal([]byte(raw), &view), "No errors for %s", raw)
+		assert.Equal(expected, view.Apps, "Apps of %s", raw)
+		assert.NotNil(view.Apps, "Apps of %s are never nil", raw)
+		assert.NotNil(view.Channels, "Channels of %s are never nil", raw)
+	}
+
+	view := viewResponse{}
+	assert.Nil(json.Unmarshal([]byte(`{"channels":[null]}`), &view), "No errors")
+	assert.Empty(view.Channels, "null channels are dropped")
+
+	assert.Error(json.Unmarshal([]byte(`{"apps":"app"}`), &viewResponse{}), "Strings are rejected")
+}
+
+func TestResponseTypes_orderChannelsByID(t *testing.T) {
+	assert := assert.New(t)
+	channel := func(id string) map[string]interface{} {
+		return map[string]interface{}{"channelid": id}
+	}
+
+	prior := []interface{}{channel("a"), channel("b"), channel("gone")}
+	fetched := []interface{}{channel("new"), channel("b"), channel("a"), channel("")}
+
+	assert.Equal(
+		[]interface{}{channel("a"), channel("b"), channel("new"), channel("")},
+		orderChannelsByID(prior, fetched),
+		"Known channels keep their order, others are appended",
+	)
+	assert.Equal(fetched, orderChannelsByID(nil, fetched), "Server order is used without a prior state")
+
+	t.Run("Matches channels without an ID by recipient", func(t *testing.T) {
+		prior := []interface{}{
+			map[string]interface{}{"channelid": "", "url": "https://b.example.com"},
+			map[string]interface{}{"channelid": "", "emails": []interface{}{"a@logdna.com"}},
+		}
+		email := map[string]interface{}{"channelid": "e1", "emails": []string{"a@logdna.com"}}
+		first := map[string]interface{}{"channelid": "w1", "url": "https://a.example.com"}
+		second := map[string]interface{}{"channelid": "w2", "url": "https://b.example.com"}
+
+		assert.Equal(
+			[]interface{}{second, email, first},
+			orderChannelsByID(prior, []interface{}{first, email, second}),
+			"Channels created in the prior order keep it",
+		)
+	})
+}
+
+func TestResponseTypes_Channels(t *testing.T) {
+	assert := assert.New(t)
+
+	raw := `{"channels": [
+		{"integration": "email", "emails": ["test@logdna.com"], "timezone": "Pacific/Samoa", "triggerinterval": 300, "url": "https://ignored"},
+		{"integration": "slack", "url": "https://hooks.slack.com/x", "immediate": "true", "key": "ignored"},
+		{"integration": "pagerduty", "key": "abc123", "alertid": 42, "timezone": "ignored"},
+		{"integration": "opsgenie", "key": "xyz"}
+	]}`
+	view := viewResponse{}
+	assert.Nil(json.Unmarshal([]byte(raw), &view), "No errors")
+	assert.Equal(Channels{
+		{Integration: EMAIL, Emails: []interface{}{"test@logdna.com"}, Timezone: "Pacific/Samoa", TriggerInterval: "5m"},
+		{Integration: SLACK, URL: "https://hooks.slack.com/x", Immediate: true},
+		{Integration: PAGERDUTY, Key: "abc123", AlertID: "42"},
+		{Integration: "opsgenie"},
+	}, view.Channels, "Each channel only has the fields of its integration")
+
+	integrations, diags := view.MapChannelsToSchema()
+	assert.Len(diags, 1, "The unsupported integration is reported")
+	assert.Equal("abc123", integrations[PAGERDUTY][0].(map[string]interface{})["key"], "The channels map to the schema")
+
+	for _, body := range []string{
+		`{"channels": [{"integration": "email", "triggerlimit": "many"}]}`,
+		`{"channels": [{"integration": 1}]}`,
+		`{"channels": {"integration": "email"}}`,
+	} {
+		assert.Error(json.Unmarshal([]byte(body), &viewResponse{}), "%s is rejected", body)
+	}
+
+	alert := alertResponse{}
+	assert.Nil(json.Unmarshal([]byte(`{"presetid": "p1", "channels": null}`), &alert), "No errors")
+	assert.Empty(alert.Channels, "No channels")
+}
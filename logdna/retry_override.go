@@ -0,0 +1,59 @@
+package logdna
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+type retryMaxKey struct{}
+
+// withRetryMax returns a ctx whose requests are retried retryMax times
+// instead of the max_retries of the provider
+func withRetryMax(ctx context.Context, retryMax int) context.Context {
+	return context.WithValue(ctx, retryMaxKey{}, retryMax)
+}
+
+// retryMaxOverride returns the retry count set on ctx by withRetryMax, if any
+func retryMaxOverride(ctx context.Context) (int, bool) {
+	retryMax, ok := ctx.Value(retryMaxKey{}).(int)
+	return retryMax, ok
+}
+
+// withRetryOverride adds the max_retries argument to a resource, which
+// overrides the max_retries of the provider for the requests of its
+// operations, e.g. to retry a resource prone to conflicts more
+func withRetryOverride(r *schema.Resource) *schema.Resource {
+	r.Schema["max_retries"] = &schema.Schema{
+		Type:         schema.TypeInt,
+		Optional:     true,
+		ValidateFunc: validation.IntAtLeast(0),
+	}
+
+	wrap := func(op func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		if op == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+			// NOTE GetOkExists tells an explicit `max_retries = 0` from an
+			//      unset argument, which inherits the provider setting
+			if retryMax, ok := d.GetOkExists("max_retries"); ok {
+				ctx = withRetryMax(ctx, retryMax.(int))
+			}
+			return op(ctx, d, m)
+		}
+	}
+	if r.UpdateContext == nil {
+		// The other arguments force a new resource, max_retries is only stored
+		r.UpdateContext = func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+			return nil
+		}
+	}
+	r.CreateContext = wrap(r.CreateContext)
+	r.ReadContext = wrap(r.ReadContext)
+	r.UpdateContext = wrap(r.UpdateContext)
+	r.DeleteContext = wrap(r.DeleteContext)
+	return r
+}
@@ -0,0 +1,81 @@
+package logdna
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// viewCache serves the reads of logdna_view from a single listing of every
+// page of views when prefetch_views is set, instead of one GET per view. An
+// entry is served once and dropped on writes, so a read following a create or
+// an update always requests the API. A nil cache is disabled.
+type viewCache struct {
+	mu     sync.Mutex
+	loaded bool
+	views  map[string][]byte
+}
+
+// take returns the body of a view from the list, loading it on first use.
+// Views missing from the list are left to an individual GET by the caller.
+func (c *viewCache) take(ctx context.Context, pc *providerConfig, viewID string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	// Held while loading so that parallel reads wait for the one listing
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loaded {
+		c.loaded = true
+		views, err := listViews(ctx, pc)
+		if err != nil {
+			log.Printf("[WARN] Cannot prefetch the views, each view is read individually: %s", err)
+		}
+		c.views = views
+	}
+
+	body, ok := c.views[viewID]
+	delete(c.views, viewID)
+	return body, ok
+}
+
+// invalidate drops a view whose cached body is outdated by a write
+func (c *viewCache) invalidate(viewID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.views, viewID)
+}
+
+// listViews fetches every view of the account, keyed by ID. The bodies are
+// kept raw to be decoded by the read like the response of a GET.
+func listViews(ctx context.Context, pc *providerConfig) (map[string][]byte, error) {
+	req := newRequestConfig(
+		pc,
+		"GET",
+		pc.endpoint("view.list"),
+		nil,
+	)
+
+	list := []json.RawMessage{}
+	if err := req.MakePaginatedRequest(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	views := make(map[string][]byte, len(list))
+	for _, raw := range list {
+		view := struct {
+			ViewID flexID `json:"viewID"`
+		}{}
+		if err := json.Unmarshal(raw, &view); err != nil {
+			return nil, err
+		}
+		views[string(view.ViewID)] = raw
+	}
+	log.Printf("[DEBUG] Prefetched %d views", len(views))
+	return views, nil
+}
@@ -0,0 +1,137 @@
+package logdna
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// viewsServer serves count views from both the list and the read endpoints
+// and counts the requests made to each. The list is paginated by offset and
+// limit.
+func viewsServer(count int) (ts *httptest.Server, lists *int32, reads *int32) {
+	lists, reads = new(int32), new(int32)
+	view := func(id string) string {
+		return fmt.Sprintf(`{"viewID": %q, "name": "view %s", "query": "test"}`, id, id)
+	}
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/config/view" {
+			atomic.AddInt32(lists, 1)
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			views := make([]string, 0, limit)
+			for i := offset; i < count && i < offset+limit; i++ {
+				views = append(views, view(fmt.Sprint(i)))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(views, ","))
+			return
+		}
+		atomic.AddInt32(reads, 1)
+		fmt.Fprint(w, view(strings.TrimPrefix(r.URL.Path, "/v1/config/view/")))
+	}))
+	return ts, lists, reads
+}
+
+func readViews(tb testing.TB, pc *providerConfig, ids []string) {
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			d := resourceView().TestResourceData()
+			d.SetId(id)
+			if diags := resourceViewRead(context.Background(), d, pc); diags.HasError() {
+				tb.Errorf("Cannot read view %s: %v", id, diags)
+			}
+		}(id)
+	}
+	wg.Wait()
+}
+
+func TestViewCache_Prefetch(t *testing.T) {
+	assert := assert.New(t)
+	ids := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		ids = append(ids, fmt.Sprint(i))
+	}
+
+	t.Run("Reads every view from a single list call", func(t *testing.T) {
+		ts, lists, reads := viewsServer(len(ids))
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}, viewCache: &viewCache{}}
+
+		readViews(t, &pc, ids)
+		assert.Equal(int32(3), atomic.LoadInt32(lists), "The views were listed once, page by page")
+		assert.Equal(int32(0), atomic.LoadInt32(reads), "No view was read individually")
+
+		d := resourceView().TestResourceData()
+		d.SetId("42")
+		diags := resourceViewRead(context.Background(), d, &pc)
+		assert.False(diags.HasError(), "No errors")
+		assert.Equal("view 42", d.Get("name"), "The view is read")
+		assert.Equal(int32(1), atomic.LoadInt32(reads), "A second read of the same view requests the API")
+	})
+
+	t.Run("Falls back to a GET for views missing from the list", func(t *testing.T) {
+		ts, lists, reads := viewsServer(1)
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}, viewCache: &viewCache{}}
+
+		readViews(t, &pc, []string{"0", "new"})
+		assert.Equal(int32(1), atomic.LoadInt32(lists), "The views were listed once")
+		assert.Equal(int32(1), atomic.LoadInt32(reads), "The missing view was read individually")
+	})
+
+	t.Run("Does not serve views which were written", func(t *testing.T) {
+		ts, _, reads := viewsServer(1)
+		defer ts.Close()
+		cache := &viewCache{}
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}, viewCache: cache}
+
+		_, ok := cache.take(context.Background(), &pc, "missing")
+		assert.False(ok, "The cache is loaded")
+		cache.invalidate("0")
+		readViews(t, &pc, []string{"0"})
+		assert.Equal(int32(1), atomic.LoadInt32(reads), "The invalidated view was read individually")
+	})
+
+	t.Run("Reads every view individually by default", func(t *testing.T) {
+		ts, lists, reads := viewsServer(len(ids))
+		defer ts.Close()
+		pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+
+		readViews(t, &pc, ids)
+		assert.Equal(int32(0), atomic.LoadInt32(lists), "The views were not listed")
+		assert.Equal(int32(len(ids)), atomic.LoadInt32(reads), "Every view was read")
+	})
+}
+
+func BenchmarkViewCache_Refresh(b *testing.B) {
+	ids := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		ids = append(ids, fmt.Sprint(i))
+	}
+	ts, _, _ := viewsServer(len(ids))
+	defer ts.Close()
+
+	for name, prefetch := range map[string]bool{"individual": false, "prefetched": true} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pc := providerConfig{baseURL: ts.URL, httpClient: &http.Client{Timeout: 15 * time.Second}}
+				if prefetch {
+					pc.viewCache = &viewCache{}
+				}
+				readViews(b, &pc, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package logdna
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// negationPrefix marks the entries of the apps and hosts of a view which are
+// excluded rather than matched, e.g. !noisy-app
+const negationPrefix = "!"
+
+// negatableFields are the view attributes accepting negated entries, along
+// with the search term excluding them. The API has no field for exclusions,
+// so they are sent as terms appended to the query.
+var negatableFields = []struct {
+	field string
+	term  string
+}{
+	{"apps", "app"},
+	{"hosts", "host"},
+}
+
+// splitNegations separates the entries to match from the names to exclude
+func splitNegations(entries []string) ([]string, []string) {
+	included, excluded := []string{}, []string{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry, negationPrefix) {
+			excluded = append(excluded, strings.TrimPrefix(entry, negationPrefix))
+			continue
+		}
+		included = append(included, entry)
+	}
+	return included, excluded
+}
+
+// exclusionTerms returns the search terms excluding the negated apps and
+// hosts of a view, e.g. -app:noisy-app -host:"build box"
+func exclusionTerms(d schemaGetter) string {
+	terms := []string{}
+	for _, f := range negatableFields {
+		_, excluded := splitNegations(listToStrings(d.Get(f.field).([]interface{})))
+		for _, name := range excluded {
+			if strings.ContainsAny(name, " \t\"") {
+				name = strconv.Quote(name)
+			}
+			terms = append(terms, fmt.Sprintf("-%s:%s", f.term, name))
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// withExclusions appends the exclusion terms to the query of a view
+func withExclusions(query string, terms string) string {
+	if terms == "" {
+		return query
+	}
+	return strings.TrimSpace(query + " " + terms)
+}
+
+// withoutExclusions strips the exclusion terms from the query read back, and
+// reports whether the query still ends with them. When it does not, e.g. the
+// query was edited outside of Terraform, the query is left as is.
+func withoutExclusions(query string, terms string) (string, bool) {
+	if terms == "" {
+		return query, true
+	}
+	if !strings.HasSuffix(query, terms) {
+		return query, false
+	}
+	return strings.TrimSpace(strings.TrimSuffix(query, terms)), true
+}
+
+// restoreNegations puts the negated entries of prior back among the entries
+// read from the API, at their position in prior, so that the order of the
+// config produces no diff
+func restoreNegations(prior []string, remote []string) []string {
+	entries := append([]string{}, remote...)
+	for i, entry := range prior {
+		if !strings.HasPrefix(entry, negationPrefix) {
+			continue
+		}
+		if i > len(entries) {
+			i = len(entries)
+		}
+		entries = append(entries[:i], append([]string{entry}, entries[i:]...)...)
+	}
+	return entries
+}
+
+// validateViewEntry rejects a negation without a name
+func validateViewEntry(val interface{}, key string) (warns []string, errs []error) {
+	entry := val.(string)
+	if strings.HasPrefix(entry, negationPrefix) && strings.TrimSpace(strings.TrimPrefix(entry, negationPrefix)) == "" {
+		errs = append(errs, fmt.Errorf("%s: %q negates no name, expected e.g. %snoisy-app", key, entry, negationPrefix))
+	}
+	return
+}
+
+// validateNegations rejects negated entries in views matching any of their
+// criteria, which would then match every line not from the excluded names
+func validateNegations(d schemaGetter) error {
+	if d.Get("match").(string) != "any" {
+		return nil
+	}
+	for _, f := range negatableFields {
+		if _, excluded := splitNegations(listToStrings(d.Get(f.field).([]interface{}))); len(excluded) > 0 {
+			return fmt.Errorf("%s cannot negate %s%s when match is \"any\", which would match every other line", f.field, negationPrefix, excluded[0])
+		}
+	}
+	return nil
+}